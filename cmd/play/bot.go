@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/engine"
+	"github.com/delta/code-runner/internal/sandbox"
+)
+
+type botOptions struct {
+	submission string // submission.py or a directory holding it
+	jail       bool   // run through nsjail like the runner, needs root and the runner image
+	python     string
+	wrapper    string
+}
+
+// seatedBot plays one seat of the game with a submission
+type seatedBot struct {
+	seat   int
+	player *engine.BotPlayer
+	s      *sandbox.Sandbox
+	close  func()
+}
+
+func openBot(ctx context.Context, opts botOptions, seat int, rules *engine.Ruleset, stderr io.Writer) (*seatedBot, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	newSandbox, err := sandbox.Local(cfg, opts.jail, opts.python, opts.wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, cleanup, err := sandbox.SubmissionDir(opts.submission)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newSandbox(ctx, dir)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("create sandbox: %w", err)
+	}
+
+	if err := s.Start(); err != nil {
+		s.Destroy()
+		cleanup()
+		return nil, fmt.Errorf("start sandbox: %w", err)
+	}
+
+	// the bot's prints and tracebacks go to the terminal as they come
+	go func() {
+		for {
+			data, err := s.RecvError(ctx)
+			if err != nil {
+				return
+			}
+			if strings.TrimSpace(string(data)) != "" {
+				fmt.Fprintf(stderr, "[bot] %s", data)
+			}
+		}
+	}()
+
+	tickTimeout := time.Duration(cfg.JailTickTimeoutMS) * time.Millisecond
+	player, err := engine.NewBotPlayer(ctx, s, cfg.JailHandshakeTimeoutMS, tickTimeout, rules)
+	if err != nil {
+		s.Destroy()
+		cleanup()
+		return nil, err
+	}
+
+	return &seatedBot{
+		seat:   seat,
+		player: player,
+		s:      s,
+		close: func() {
+			s.Destroy()
+			cleanup()
+		},
+	}, nil
+}
+
+// turn asks the bot for its moves and applies them. A failed turn is
+// reported and played as an empty one so the human can keep probing.
+func (b *seatedBot) turn(ctx context.Context, ge *engine.GameEngine, out io.Writer) {
+	move, err := b.player.Move(ctx, ge, b.seat)
+	if err != nil {
+		fmt.Fprintf(out, "bot turn failed: %v\n", err)
+		move = engine.PlayerMoves{}
+	}
+
+	fmt.Fprintf(out, "Bot (player %d) moves, in its own perspective:\n", b.seat)
+	if len(move.Spawns) == 0 && len(move.Actions) == 0 {
+		fmt.Fprintln(out, "  (nothing)")
+	}
+	for _, id := range slices.Sorted(maps.Keys(move.Spawns)) {
+		sp := move.Spawns[id]
+		fmt.Fprintf(out, "  SPAWN Bot %d at %v with %v\n", id, sp.Location, sp.Abilities)
+	}
+	for _, id := range slices.Sorted(maps.Keys(move.Actions)) {
+		a := move.Actions[id]
+		fmt.Fprintf(out, "  ACTION Bot %d %s %s\n", id, a.Direction, a.Action)
+	}
+
+	ge.UpdateState(move)
+
+	for _, r := range ge.Results[b.seat] {
+		if r.Code != engine.ResultOK {
+			fmt.Fprintf(out, "  %s Bot %d: %s\n", r.Type, r.BotID, r.Code)
+		}
+	}
+}
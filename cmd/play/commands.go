@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// session holds the moves queued for the player whose turn is next
+type session struct {
+	ge      *engine.GameEngine
+	pending engine.PlayerMoves
+
+	// optional submission playing one seat, its turns are taken right after
+	// the human's
+	bot    *seatedBot
+	botCtx context.Context
+	botOut io.Writer
+}
+
+func newSession(ge *engine.GameEngine) *session {
+	s := &session{ge: ge}
+	s.reset()
+	return s
+}
+
+func (s *session) reset() {
+	s.pending = engine.PlayerMoves{
+		Spawns:  make(map[int]engine.SpawnCmd),
+		Actions: make(map[int]engine.ActionCmd),
+	}
+}
+
+// assertError is a failed ASSERT, any other exec error is a bad command
+type assertError struct {
+	msg string
+}
+
+func (e *assertError) Error() string {
+	return e.msg
+}
+
+func assertf(format string, a ...any) error {
+	return &assertError{msg: fmt.Sprintf(format, a...)}
+}
+
+func (s *session) exec(parts []string) error {
+	switch strings.ToUpper(parts[0]) {
+	case "NEXT":
+		s.next()
+
+	case "PASS":
+		if len(parts) < 2 {
+			return errors.New("usage: PASS <n>")
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Errorf("PASS: %w", err)
+		}
+		for range n {
+			s.next()
+		}
+
+	case "SPAWN":
+		if len(parts) < 4 {
+			return errors.New("usage: SPAWN <botID> <x> <y> <abilities...>")
+		}
+		nums, err := atois(parts[1:4])
+		if err != nil {
+			return fmt.Errorf("SPAWN: %w", err)
+		}
+
+		abilities := parts[4:]
+		for i := range abilities {
+			abilities[i] = strings.ToUpper(abilities[i])
+		}
+
+		s.pending.Spawns[nums[0]] = engine.SpawnCmd{
+			Location:  engine.Point{X: nums[1], Y: nums[2]},
+			Abilities: abilities,
+		}
+
+	case "ACTION":
+		if len(parts) < 4 {
+			return errors.New("usage: ACTION <botID> <direction> <verb>")
+		}
+		botID, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Errorf("ACTION: %w", err)
+		}
+
+		s.pending.Actions[botID] = engine.ActionCmd{
+			Direction: strings.ToUpper(parts[2]),
+			Action:    strings.ToUpper(parts[3]),
+		}
+
+	case "ASSERT":
+		return s.assert(parts[1:])
+
+	default:
+		return fmt.Errorf("unknown command %q", parts[0])
+	}
+
+	return nil
+}
+
+func (s *session) next() {
+	s.pending.Tick = s.ge.Ticks + 1
+	s.ge.UpdateState(s.pending)
+	s.reset()
+	s.botTurns()
+}
+
+// botTurns lets the seated bot play while it is its turn
+func (s *session) botTurns() {
+	for s.bot != nil && s.ge.Winner == -1 && (s.ge.Ticks+1)%2 == s.bot.seat {
+		s.bot.turn(s.botCtx, s.ge, s.botOut)
+	}
+}
+
+// assert checks one fact about the board, in board coordinates:
+//
+//	ASSERT BOT <id> AT <x> <y>
+//	ASSERT BOT <id> ENERGY <energy>
+//	ASSERT BOT <id> HELD <algae>
+//	ASSERT BOT <id> ALIVE|DEAD
+//	ASSERT SCRAPS <player> <scraps>
+//	ASSERT ALGAE <player> <deposited algae>
+//	ASSERT WINNER 0|1|DRAW|NONE
+//	ASSERT TICK <tick>
+func (s *session) assert(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: ASSERT BOT|SCRAPS|ALGAE|WINNER|TICK ...")
+	}
+
+	ge := s.ge
+
+	switch strings.ToUpper(args[0]) {
+	case "BOT":
+		if len(args) < 3 {
+			return errors.New("usage: ASSERT BOT <id> AT|ENERGY|HELD|ALIVE|DEAD ...")
+		}
+		botID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("ASSERT BOT: %w", err)
+		}
+		bot := ge.AllBots[botID]
+		what := strings.ToUpper(args[2])
+
+		switch what {
+		case "ALIVE":
+			if bot == nil {
+				return assertf("bot %d is dead", botID)
+			}
+			return nil
+		case "DEAD":
+			if bot != nil {
+				return assertf("bot %d is alive at %v", botID, bot.Location)
+			}
+			return nil
+		}
+
+		if bot == nil {
+			return assertf("bot %d is dead", botID)
+		}
+
+		switch what {
+		case "AT":
+			if len(args) < 5 {
+				return errors.New("usage: ASSERT BOT <id> AT <x> <y>")
+			}
+			nums, err := atois(args[3:5])
+			if err != nil {
+				return fmt.Errorf("ASSERT BOT AT: %w", err)
+			}
+			if want := (engine.Point{X: nums[0], Y: nums[1]}); bot.Location != want {
+				return assertf("bot %d at %v, want %v", botID, bot.Location, want)
+			}
+		case "ENERGY":
+			if len(args) < 4 {
+				return errors.New("usage: ASSERT BOT <id> ENERGY <energy>")
+			}
+			want, err := strconv.ParseFloat(args[3], 64)
+			if err != nil {
+				return fmt.Errorf("ASSERT BOT ENERGY: %w", err)
+			}
+			if math.Abs(bot.Energy-want) > 1e-9 {
+				return assertf("bot %d has %v energy, want %v", botID, bot.Energy, want)
+			}
+		case "HELD":
+			if len(args) < 4 {
+				return errors.New("usage: ASSERT BOT <id> HELD <algae>")
+			}
+			want, err := strconv.Atoi(args[3])
+			if err != nil {
+				return fmt.Errorf("ASSERT BOT HELD: %w", err)
+			}
+			if bot.AlgaeHeld != want {
+				return assertf("bot %d holds %d algae, want %d", botID, bot.AlgaeHeld, want)
+			}
+		default:
+			return fmt.Errorf("unknown bot assertion %q", args[2])
+		}
+
+	case "SCRAPS", "ALGAE":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: ASSERT %s <player> <n>", strings.ToUpper(args[0]))
+		}
+		nums, err := atois(args[1:3])
+		if err != nil {
+			return fmt.Errorf("ASSERT %s: %w", args[0], err)
+		}
+		player, want := nums[0], nums[1]
+		if player != engine.PlayerOne && player != engine.PlayerTwo {
+			return fmt.Errorf("invalid player %d", player)
+		}
+
+		got := ge.Scraps[player]
+		if strings.ToUpper(args[0]) == "ALGAE" {
+			got = ge.PermanentAlgae[player]
+		}
+		if got != want {
+			return assertf("player %d has %d %s, want %d", player, got, strings.ToLower(args[0]), want)
+		}
+
+	case "WINNER":
+		if len(args) < 2 {
+			return errors.New("usage: ASSERT WINNER 0|1|DRAW|NONE")
+		}
+		var want int
+		switch strings.ToUpper(args[1]) {
+		case "NONE":
+			want = -1
+		case "DRAW":
+			want = engine.Draw
+		default:
+			n, err := strconv.Atoi(args[1])
+			if err != nil || (n != engine.PlayerOne && n != engine.PlayerTwo) {
+				return fmt.Errorf("invalid winner %q", args[1])
+			}
+			want = n
+		}
+		if ge.Winner != want {
+			return assertf("winner is %d, want %d", ge.Winner, want)
+		}
+
+	case "TICK":
+		if len(args) < 2 {
+			return errors.New("usage: ASSERT TICK <tick>")
+		}
+		want, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("ASSERT TICK: %w", err)
+		}
+		if ge.Ticks != want {
+			return assertf("tick is %d, want %d", ge.Ticks, want)
+		}
+
+	default:
+		return fmt.Errorf("unknown assertion %q", args[0])
+	}
+
+	return nil
+}
+
+func atois(ss []string) ([]int, error) {
+	nums := make([]int, len(ss))
+	for i, s := range ss {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
@@ -2,27 +2,112 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/delta/code-runner/internal/engine"
 )
 
 func main() {
+	mapName := flag.String("map", engine.DefaultMapName, "map to play on")
+	mapsDir := flag.String("maps", "maps", "directory with map files, built-in maps are used as fallback")
+	rulesetName := flag.String("ruleset", engine.DefaultRulesetName, "ruleset to play with")
+	rulesetsDir := flag.String("rulesets", "rulesets", "directory with ruleset files, built-in rulesets are used as fallback")
+	seed := flag.Int64("seed", 1, "board seed, random when not set for interactive play")
+	script := flag.String("script", "", "run the commands in this file instead of reading stdin, exits 1 on a failed ASSERT")
+	jsonOut := flag.Bool("json", false, "with -script, print the result and final state as JSON")
+	logPath := flag.String("log", "", "with -script, write the game log to this file")
+	botPath := flag.String("bot", "", "submission.py, or a directory holding it, to play one seat")
+	botSeat := flag.Int("bot-seat", 1, "player the -bot submission plays, 0 or 1")
+	jail := flag.Bool("jail", false, "run the -bot submission in nsjail like the runner (root, runner image) instead of a local python")
+	python := flag.String("python", "python3", "python for an unjailed -bot submission")
+	wrapper := flag.String("wrapper", "wrapper.py", "wrapper for an unjailed -bot submission")
+	flag.Parse()
+
+	if *botSeat != engine.PlayerOne && *botSeat != engine.PlayerTwo {
+		fmt.Println("-bot-seat must be 0 or 1")
+		os.Exit(1)
+	}
+
+	gameMap, err := engine.LoadMap(*mapsDir, *mapName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rules, err := engine.LoadRuleset(*rulesetsDir, *rulesetName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *script != "" {
+		var logW io.Writer = io.Discard
+		if *logPath != "" {
+			f, err := os.Create(*logPath)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(2)
+			}
+			defer f.Close()
+			logW = f
+		}
+
+		ge := engine.NewSeededGameEngine(engine.NewGameLogger(logW), gameMap, rules, *seed)
+		s := newSession(ge)
+		if *botPath != "" {
+			bot, err := openBot(context.Background(), botOptions{*botPath, *jail, *python, *wrapper}, *botSeat, rules, os.Stderr)
+			if err != nil {
+				fmt.Println("bot:", err)
+				os.Exit(2)
+			}
+			s.bot, s.botCtx, s.botOut = bot, context.Background(), os.Stderr
+		}
+
+		code := runScript(s, *script, *jsonOut)
+		if s.bot != nil {
+			s.bot.close()
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
+		return
+	}
+
+	seedSet := false
+	flag.Visit(func(f *flag.Flag) {
+		seedSet = seedSet || f.Name == "seed"
+	})
+
 	gl := engine.NewGameLogger(os.Stdout)
-	ge := engine.InitGameEngine(gl)
+	var ge *engine.GameEngine
+	if seedSet {
+		ge = engine.NewSeededGameEngine(gl, gameMap, rules, *seed)
+	} else {
+		ge = engine.NewGameEngine(gl, gameMap, rules)
+	}
 	reader := bufio.NewReader(os.Stdin)
+	s := newSession(ge)
 
-	pendingMoves := engine.PlayerMoves{
-		Spawns:  make(map[int]engine.SpawnCmd),
-		Actions: make(map[int]engine.ActionCmd),
+	if *botPath != "" {
+		bot, err := openBot(context.Background(), botOptions{*botPath, *jail, *python, *wrapper}, *botSeat, rules, os.Stderr)
+		if err != nil {
+			fmt.Println("bot:", err)
+			os.Exit(1)
+		}
+		defer bot.close()
+		s.bot, s.botCtx, s.botOut = bot, context.Background(), os.Stdout
+		fmt.Printf("Submission %s plays player %d (protocol v%d/%s)\n", *botPath, *botSeat, bot.player.Protocol.Version, bot.player.Protocol.Encoding)
 	}
 
 	fmt.Println("Ocean Master CLI Simulator")
 	printHelp()
+	s.botTurns()
 
 	for {
 		// Calculate whose turn it is for the *pending* moves
@@ -34,88 +119,42 @@ func main() {
 		printState(ge)
 
 		// Print pending moves
-		if len(pendingMoves.Spawns) > 0 || len(pendingMoves.Actions) > 0 {
+		if len(s.pending.Spawns) > 0 || len(s.pending.Actions) > 0 {
 			fmt.Println("Pending Moves:")
-			for id, s := range pendingMoves.Spawns {
-				fmt.Printf("  SPAWN Bot %d at %v with %v\n", id, s.Location, s.Abilities)
+			for id, sp := range s.pending.Spawns {
+				fmt.Printf("  SPAWN Bot %d at %v with %v\n", id, sp.Location, sp.Abilities)
 			}
-			for id, a := range pendingMoves.Actions {
+			for id, a := range s.pending.Actions {
 				fmt.Printf("  ACTION Bot %d %s %s\n", id, a.Direction, a.Action)
 			}
 		}
 
 		fmt.Print("> ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			return
+		}
 		parts := strings.Fields(input)
 
 		if len(parts) == 0 {
 			continue
 		}
 
-		cmd := strings.ToUpper(parts[0])
-
-		switch cmd {
+		switch cmd := strings.ToUpper(parts[0]); cmd {
 		case "QUIT":
 			return
 		case "HELP":
 			printHelp()
-		case "NEXT":
-			pendingMoves.Tick = ge.Ticks+1
-			ge.UpdateState(pendingMoves)
-			// Reset pending moves for next turn
-			pendingMoves = engine.PlayerMoves{
-				Spawns:  make(map[int]engine.SpawnCmd),
-				Actions: make(map[int]engine.ActionCmd),
-			}
-		case "SPAWN":
-			if len(parts) < 4 {
-				fmt.Println("Usage: SPAWN <botID> <x> <y> <abilities...>")
-				continue
-			}
-			botID, _ := strconv.Atoi(parts[1])
-			x, _ := strconv.Atoi(parts[2])
-			y, _ := strconv.Atoi(parts[3])
-			abilities := parts[4:]
-
-			// Validate abilities being upper case
-			for i := range abilities {
-				abilities[i] = strings.ToUpper(abilities[i])
-			}
-
-			pendingMoves.Spawns[botID] = engine.SpawnCmd{
-				Location:  engine.Point{X: x, Y: y},
-				Abilities: abilities,
-			}
-			fmt.Println("Spawn queued.")
-
-		case "ACTION":
-			if len(parts) < 4 {
-				fmt.Println("Usage: ACTION <botID> <direction> <verb>")
-				continue
-			}
-			botID, _ := strconv.Atoi(parts[1])
-			direction := strings.ToUpper(parts[2])
-			verb := strings.ToUpper(parts[3])
-
-			pendingMoves.Actions[botID] = engine.ActionCmd{
-				Direction: direction,
-				Action:    verb,
-			}
-			fmt.Println("Action queued.")
-        case "PASS":
-            num, _ := strconv.Atoi(parts[1])
-            for _ = range num{
-                pendingMoves.Tick = ge.Ticks+1
-                ge.UpdateState(pendingMoves)
-                pendingMoves = engine.PlayerMoves{
-                    Spawns:  make(map[int]engine.SpawnCmd),
-                    Actions: make(map[int]engine.ActionCmd),
-                }
-            }
-
 		default:
-			fmt.Println("Unknown command. Type HELP.")
+			if err := s.exec(parts); err != nil {
+				fmt.Println(err)
+			} else if cmd == "SPAWN" {
+				fmt.Println("Spawn queued.")
+			} else if cmd == "ACTION" {
+				fmt.Println("Action queued.")
+			} else if cmd == "ASSERT" {
+				fmt.Println("ok")
+			}
 		}
 	}
 }
@@ -153,8 +192,8 @@ func printState(ge *engine.GameEngine) {
 			bot.ID, bot.OwnerID, bot.Location, bot.Energy, bot.Scraps, bot.AlgaeHeld, bot.Abilities)
 	}
 
-    for y := range 20 {
-        for x := range 20 {
+    for y := range ge.Height {
+        for x := range ge.Width {
             isbank := false
             ispad  := false
             point := engine.Point{X:x,Y:y}
@@ -200,6 +239,11 @@ func printHelp() {
 	fmt.Println("  ACTION <botID> <dir> <verb>           (e.g., ACTION 1 NORTH HARVEST)")
 	fmt.Println("    Dirs: NORTH, SOUTH, EAST, WEST, NULL")
 	fmt.Println("    Verbs: HARVEST, DEPOSIT, LOCKPICK, POISON, SELFDESTRUCT, NIL")
+	fmt.Println("    Player 1 sees the board rotated half a turn: x -> width-1-x, y -> height-1-y,")
+	fmt.Println("    NORTH <-> SOUTH, EAST <-> WEST. Their SPAWN and ACTION use those coordinates.")
 	fmt.Println("  NEXT                                  (Commit moves and advance turn)")
+	fmt.Println("  PASS <n>                              (Commit moves, then n-1 empty turns)")
+	fmt.Println("  ASSERT BOT <id> AT <x> <y> | ENERGY <e> | HELD <n> | ALIVE | DEAD")
+	fmt.Println("  ASSERT SCRAPS|ALGAE <player> <n>, ASSERT WINNER 0|1|DRAW|NONE, ASSERT TICK <n>")
 	fmt.Println("  QUIT")
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/delta/code-runner/internal/engine"
+)
+
+type scriptFailure struct {
+	Line    int    `json:"line"`
+	Command string `json:"command"`
+	Error   string `json:"error"`
+}
+
+type scriptResult struct {
+	Script   string             `json:"script"`
+	Seed     int64              `json:"seed"`
+	Passed   int                `json:"passed"`
+	Failures []scriptFailure    `json:"failures"`
+	State    engine.GameViewDTO `json:"state"`
+}
+
+// runScript plays the commands in path, one per line with # comments, and
+// returns the exit code: 0 when every ASSERT held, 1 when one failed and 2
+// when the script itself is broken
+func runScript(s *session, path string, jsonOut bool) int {
+	ge := s.ge
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer f.Close()
+
+	res := scriptResult{
+		Script:   path,
+		Seed:     ge.Seed,
+		Failures: make([]scriptFailure, 0),
+	}
+
+	// a seated bot moving first plays before the script starts
+	s.botTurns()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		parts := strings.Fields(text)
+		if len(parts) == 0 {
+			continue
+		}
+
+		err := s.exec(parts)
+
+		var failed *assertError
+		switch {
+		case errors.As(err, &failed):
+			res.Failures = append(res.Failures, scriptFailure{Line: line, Command: text, Error: err.Error()})
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "%s:%d: %v\n", path, line, err)
+			return 2
+		case strings.EqualFold(parts[0], "ASSERT"):
+			res.Passed++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	res.State = ge.GameView()
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(res)
+	} else {
+		for _, f := range res.Failures {
+			fmt.Printf("FAIL %s:%d: %s: %s\n", path, f.Line, f.Command, f.Error)
+		}
+		fmt.Printf("%d passed, %d failed\n", res.Passed, len(res.Failures))
+	}
+
+	if len(res.Failures) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+// ratings rates players by Elo or Glicko-2 from match records
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/delta/code-runner/internal/engine"
+	"github.com/delta/code-runner/internal/ratings"
+)
+
+type options struct {
+	system       string
+	statePath    string
+	recompute    bool
+	rateForfeits bool
+	forfeitsSet  bool // -rate-forfeits was given, a saved table must agree
+	k            float64
+	period       time.Duration
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.system, "system", "glicko2", "elo or glicko2")
+	flag.StringVar(&opts.statePath, "state", "", "ratings file kept between runs, only games it has not seen are rated on top of it")
+	flag.BoolVar(&opts.recompute, "recompute", false, "ignore the ratings in -state and rate every given game from scratch")
+	flag.BoolVar(&opts.rateForfeits, "rate-forfeits", true, "rate forfeits as losses, otherwise they are only counted")
+	flag.Float64Var(&opts.k, "k", 32, "elo K factor")
+	flag.DurationVar(&opts.period, "period", 24*time.Hour, "glicko2 rating period")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <result files or dirs, - for JSON lines on stdin>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "rate-forfeits" {
+			opts.forfeitsSet = true
+		}
+	})
+
+	if err := run(opts, flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(opts options, paths []string) error {
+	sys, err := ratings.NewSystem(opts.system)
+	if err != nil {
+		return err
+	}
+	switch s := sys.(type) {
+	case *ratings.Elo:
+		s.K = opts.k
+	case *ratings.Glicko2:
+		s.Period = opts.period
+	}
+
+	table := ratings.NewTable(sys, opts.rateForfeits)
+	if opts.statePath != "" && !opts.recompute {
+		saved, err := ratings.LoadTable(opts.statePath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return err
+		case saved.System != sys.Name():
+			return fmt.Errorf("%s holds %s ratings, rerun with -recompute to rate with %s", opts.statePath, saved.System, sys.Name())
+		case opts.forfeitsSet && saved.RateForfeits != opts.rateForfeits:
+			return fmt.Errorf("%s was rated with -rate-forfeits=%t, rerun with -recompute to change it", opts.statePath, saved.RateForfeits)
+		default:
+			table = saved
+		}
+	}
+
+	recs, err := readAll(paths)
+	if err != nil {
+		return err
+	}
+
+	games := make([]ratings.Game, 0, len(recs))
+	skipped := 0
+	for _, rec := range recs {
+		if g, ok := ratings.FromRecord(rec); ok {
+			games = append(games, g)
+		} else {
+			skipped++
+		}
+	}
+
+	applied, err := table.Apply(sys, games)
+	if err != nil {
+		return err
+	}
+	log.Printf("rated %d new games, %d already rated, %d counted for nobody", applied, len(games)-applied, skipped)
+
+	printTable(os.Stdout, sys, table)
+
+	if opts.statePath != "" {
+		if err := table.Save(opts.statePath); err != nil {
+			return fmt.Errorf("save ratings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readAll(paths []string) ([]engine.MatchRecord, error) {
+	recs := make([]engine.MatchRecord, 0)
+	for _, path := range paths {
+		var (
+			more []engine.MatchRecord
+			err  error
+		)
+		if path == "-" {
+			more, err = ratings.ReadRecordStream(os.Stdin)
+		} else {
+			more, err = ratings.ReadRecords(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		recs = append(recs, more...)
+	}
+	return recs, nil
+}
+
+func printTable(w io.Writer, sys ratings.System, table *ratings.Table) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, glicko := sys.(*ratings.Glicko2)
+	if glicko {
+		fmt.Fprintln(tw, "#\tPlayer\tRating\tDeviation\tGames\tW\tD\tL\tForfeits")
+	} else {
+		fmt.Fprintln(tw, "#\tPlayer\tRating\tGames\tW\tD\tL\tForfeits")
+	}
+
+	for i, r := range table.Ranked() {
+		if glicko {
+			fmt.Fprintf(tw, "%d\t%s\t%.0f\t%.0f\t%d\t%d\t%d\t%d\t%d\n", i+1, r.Player, r.Rating, r.Deviation, r.Games, r.Wins, r.Draws, r.Losses, r.Forfeits)
+		} else {
+			fmt.Fprintf(tw, "%d\t%s\t%.0f\t%d\t%d\t%d\t%d\t%d\n", i+1, r.Player, r.Rating, r.Games, r.Wins, r.Draws, r.Losses, r.Forfeits)
+		}
+	}
+
+	tw.Flush()
+}
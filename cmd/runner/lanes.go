@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/queue"
+	"github.com/delta/code-runner/internal/semaphore"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// lane consumes one queue, its matches take slots of the shared pool
+type lane struct {
+	index int // in the pool and cfg.Lanes
+	name  string
+	q     *queue.MatchJobQueue
+	share int
+	msgs  <-chan amqp091.Delivery
+
+	// new consumer channels after a prefetch change
+	consumers chan (<-chan amqp091.Delivery)
+}
+
+// openLanes opens the queue of every lane. Any lane may borrow every slot,
+// so each prefetches up to MaxConcurrentMatches deliveries.
+func openLanes(cfg *config.Config) ([]*lane, *semaphore.Lanes, error) {
+	lanes := make([]*lane, 0, len(cfg.Lanes))
+	fail := func(err error) ([]*lane, *semaphore.Lanes, error) {
+		closeLanes(lanes)
+		return nil, nil, err
+	}
+
+	for i, l := range cfg.Lanes {
+		q, err := queue.NewMatchJobQueue(cfg.MatchJobQueueConfig.Lane(l.Name))
+		if err != nil {
+			return fail(err)
+		}
+		ln := &lane{
+			index:     i,
+			name:      l.Name,
+			q:         q,
+			share:     l.Share,
+			consumers: make(chan (<-chan amqp091.Delivery)),
+		}
+		lanes = append(lanes, ln)
+
+		if err := q.SetMaxConcurrentMatches(cfg.MaxConcurrentMatches); err != nil {
+			return fail(err)
+		}
+
+		ln.msgs, err = q.Consume()
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	return lanes, semaphore.NewLanes(cfg.MaxConcurrentMatches, shares(cfg.Lanes)), nil
+}
+
+func shares(lanes []config.Lane) []int {
+	out := make([]int, len(lanes))
+	for i, l := range lanes {
+		out[i] = l.Share
+	}
+	return out
+}
+
+func closeLanes(lanes []*lane) {
+	for _, l := range lanes {
+		l.q.Close()
+	}
+}
+
+// lanes are fixed for the life of the runner, only their shares can be reloaded
+func sameLanes(prev, next []config.Lane) error {
+	if len(prev) != len(next) {
+		return fmt.Errorf("RABBITMQ_LANES changed lanes, restart the runner to apply")
+	}
+	for i := range prev {
+		if prev[i].Name != next[i].Name {
+			return fmt.Errorf("RABBITMQ_LANES changed lanes, restart the runner to apply")
+		}
+	}
+	return nil
+}
+
+func (l *lane) String() string {
+	if l.name == "" {
+		return "default lane"
+	}
+	return "lane " + l.name
+}
+
+// consume hands every delivery to handle until the queue's connection closes.
+// The broker prefetch bounds how many deliveries can wait for a slot.
+func (l *lane) consume(handle func(*lane, amqp091.Delivery)) {
+	msgs := l.msgs
+	for {
+		select {
+		case next := <-l.consumers:
+			// a cancelled consumer still hands out what it had buffered
+			go func(old <-chan amqp091.Delivery) {
+				for d := range old {
+					go handle(l, d)
+				}
+			}(msgs)
+			msgs = next
+		case d, ok := <-msgs:
+			if !ok {
+				return
+			}
+			go handle(l, d)
+		}
+	}
+}
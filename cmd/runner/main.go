@@ -3,17 +3,23 @@ package main
 import (
 	"encoding/json"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/delta/code-runner/internal/admin"
 	"github.com/delta/code-runner/internal/cgroup"
 	"github.com/delta/code-runner/internal/config"
 	"github.com/delta/code-runner/internal/manager"
 	"github.com/delta/code-runner/internal/nsjail"
-	"github.com/delta/code-runner/internal/queue"
 	"github.com/rabbitmq/amqp091-go"
 )
 
 func run() error {
-	cfg := config.New()
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
 
 	cg, err := cgroup.UnshareAndMount()
 	if err != nil {
@@ -26,54 +32,90 @@ func run() error {
 	}
 
 	gameManager := manager.NewGameManager(cfg)
+	defer gameManager.Close()
 
-	matchJobQ, err := queue.NewMatchJobQueue(cfg.MatchJobQueueConfig)
+	// every lane has its own queue, the slots are shared and lent between
+	// lanes so a flood in one lane cannot starve another
+	lanes, slots, err := openLanes(cfg)
 	if err != nil {
 		return err
 	}
-	defer matchJobQ.Close()
-
-	err = matchJobQ.SetMaxConcurrentMatches(cfg.MaxConcurrentMatches)
-	if err != nil {
-		return err
+	defer closeLanes(lanes)
+
+	r := &reloader{
+		cg:                    cg,
+		gameManager:           gameManager,
+		lanes:                 lanes,
+		slots:                 slots,
+		baseNsjailCfgPath:     cfg.NsjailCfgPath,
+		baseNsjailWarmCfgPath: cfg.NsjailWarmCfgPath,
+		done:                  make(chan struct{}),
 	}
-
-	msgs, err := matchJobQ.Consume()
-	if err != nil {
-		return err
+	defer close(r.done)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := r.reload(); err != nil {
+				log.Println("RELOAD FAILED:", err)
+			}
+		}
+	}()
+
+	if cfg.AdminAddr != "" {
+		adminSrv := admin.NewServer(cfg.AdminAddr)
+		r.registerAdmin(adminSrv)
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil {
+				log.Println("ADMIN API STOPPED:", err)
+			}
+		}()
 	}
 
-	sem := make(chan struct{}, cfg.MaxConcurrentMatches)
-
-	log.Printf("consumer started with %d max_concurrency)\n", cfg.MaxConcurrentMatches)
+	if cfg.SpectateAddr != "" {
+		go func() {
+			if err := gameManager.Spectators().ListenAndServe(cfg.SpectateAddr); err != nil {
+				log.Println("SPECTATOR API STOPPED:", err)
+			}
+		}()
+	}
 
-	for d := range msgs {
-		sem <- struct{}{} // blocks if max concurrency reached
+	handle := func(l *lane, delivery amqp091.Delivery) {
+		slots.Acquire(l.index) // blocks while every slot is taken
+		defer slots.Release(l.index)
 
-		go func(delivery amqp091.Delivery) {
-			defer func() { <-sem }()
+		var job manager.MatchJob
+		if err := json.Unmarshal(delivery.Body, &job); err != nil {
+			log.Println("INVALID JOB:", err)
+			delivery.Nack(false, false) // discard
+			return
+		}
 
-			var job manager.MatchJob
-			if err := json.Unmarshal(delivery.Body, &job); err != nil {
-				log.Println("INVALID JOB:", err)
-				delivery.Nack(false, false) // discard
-				return
-			}
+		log.Println("RUNNING MATCH:", job.ID, "in", l)
 
-			log.Println("RUNNING MATCH:", job.ID)
+		if err := gameManager.NewMatch(job); err != nil {
+			log.Println("MATCH FAILED:", err)
+			delivery.Nack(false, false) // discard
+			// TODO: Try atleast twice and then discard
+			return
+		}
 
-			if err := gameManager.NewMatch(job); err != nil {
-				log.Println("MATCH FAILED:", err)
-				delivery.Nack(false, false) // discard
-				// TODO: Try atleast twice and then discard
-				return
-			}
+		log.Println("MATCH FINISHED:", job.ID)
+		delivery.Ack(false)
+	}
 
-			log.Println("MATCH FINISHED:", job.ID)
-			delivery.Ack(false)
-		}(d)
+	stopped := make(chan struct{}, len(lanes))
+	for _, l := range lanes {
+		log.Printf("consumer started on %s with a share of %d of %d max_concurrency\n", l, l.share, cfg.MaxConcurrentMatches)
+		go func() {
+			l.consume(handle)
+			stopped <- struct{}{}
+		}()
 	}
 
+	// a closed connection stops the runner, like with a single queue
+	<-stopped
 	return nil
 }
 
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/delta/code-runner/internal/admin"
+	"github.com/delta/code-runner/internal/cgroup"
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/manager"
+	"github.com/delta/code-runner/internal/nsjail"
+	"github.com/delta/code-runner/internal/semaphore"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// reloader applies a freshly loaded config to the running runner.
+// In-flight matches hold their own *config.Config and nsjail.cfg generation,
+// so only matches started after a reload see the new values.
+type reloader struct {
+	mu sync.Mutex
+
+	cg          cgroup.Cgroup
+	gameManager *manager.GameManager
+	lanes       []*lane
+	slots       *semaphore.Lanes
+
+	baseNsjailCfgPath     string
+	baseNsjailWarmCfgPath string
+	generation            int
+
+	done chan struct{}
+}
+
+func (r *reloader) reload() (*config.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	prev := r.gameManager.Config()
+
+	if err := sameLanes(prev.Lanes, cfg.Lanes); err != nil {
+		return nil, err
+	}
+
+	// every generation gets its own file so jails of running matches
+	// are never started from a half written config
+	r.generation++
+	cfg.NsjailCfgPath = fmt.Sprintf("%s.%d", r.baseNsjailCfgPath, r.generation)
+	cfg.NsjailWarmCfgPath = fmt.Sprintf("%s.%d", r.baseNsjailWarmCfgPath, r.generation)
+
+	if _, err := nsjail.WriteConfig(cfg, r.cg); err != nil {
+		removeNsjailConfig(cfg)
+		return nil, fmt.Errorf("write nsjail config: %w", err)
+	}
+
+	r.slots.Resize(cfg.MaxConcurrentMatches, shares(cfg.Lanes))
+	for i, l := range r.lanes {
+		l.share = cfg.Lanes[i].Share
+	}
+
+	if cfg.MaxConcurrentMatches != prev.MaxConcurrentMatches {
+		for _, l := range r.lanes {
+			err := l.q.Resubscribe(cfg.MaxConcurrentMatches, func(msgs <-chan amqp091.Delivery) {
+				select {
+				case l.consumers <- msgs:
+				case <-r.done:
+				}
+			})
+			if err != nil {
+				removeNsjailConfig(cfg)
+				return nil, fmt.Errorf("resubscribe %s: %w", l, err)
+			}
+		}
+	}
+
+	// a generation's files go once the matches started with it have ended,
+	// the base files written at start are kept
+	r.gameManager.SetConfig(cfg, func() { removeNsjailConfig(cfg) })
+
+	log.Printf("config reloaded (generation %d, %d max_concurrency)\n", r.generation, cfg.MaxConcurrentMatches)
+
+	return cfg, nil
+}
+
+func removeNsjailConfig(cfg *config.Config) {
+	for _, p := range []string{cfg.NsjailCfgPath, cfg.NsjailWarmCfgPath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Println("REMOVE NSJAIL CONFIG FAILED:", err)
+		}
+	}
+}
+
+func (r *reloader) registerAdmin(s *admin.Server) {
+	s.HandleFunc("POST /reload", func(w http.ResponseWriter, _ *http.Request) {
+		cfg, err := r.reload()
+		if err != nil {
+			admin.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		shares := make(map[string]int, len(cfg.Lanes))
+		for _, l := range cfg.Lanes {
+			name := l.Name
+			if name == "" {
+				name = config.DefaultLane
+			}
+			shares[name] = l.Share
+		}
+
+		admin.WriteJSON(w, http.StatusOK, map[string]any{
+			"nsjail_cfg":             cfg.NsjailCfgPath,
+			"max_concurrent_matches": cfg.MaxConcurrentMatches,
+			"lanes":                  shares,
+		})
+	})
+}
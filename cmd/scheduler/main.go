@@ -0,0 +1,175 @@
+// scheduler keeps the runner busy with matches between the active submissions
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/engine"
+	"github.com/delta/code-runner/internal/manager"
+	"github.com/delta/code-runner/internal/matchmaking"
+	"github.com/delta/code-runner/internal/queue"
+	"github.com/delta/code-runner/internal/ratings"
+)
+
+type options struct {
+	dir         string
+	ratingsPath string
+	interval    time.Duration
+	perRound    int
+	maxMatches  int
+	window      time.Duration
+	exploration float64
+	seed        int64
+	once        bool
+	dryRun      bool
+	lane        string
+	rulesetsDir string
+	template    manager.MatchJob // mode, map, ruleset and games of every job
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.dir, "dir", "submissions", "active submissions, one <player id>.py or <player id>/submission.py each, reread every round")
+	flag.StringVar(&opts.ratingsPath, "ratings", "", "ratings file of cmd/ratings -state, reread every round, everyone is rated equally without it")
+	flag.DurationVar(&opts.interval, "interval", time.Minute, "time between rounds")
+	flag.IntVar(&opts.perRound, "per-round", 4, "matches published per round at most")
+	flag.IntVar(&opts.maxMatches, "max-matches", 10, "matches per player within -window, 0 for no limit")
+	flag.DurationVar(&opts.window, "window", time.Hour, "window of -max-matches")
+	flag.Float64Var(&opts.exploration, "exploration", 0.2, "share of pairings with a random opponent instead of the closest rating")
+	flag.Int64Var(&opts.seed, "seed", time.Now().UnixNano(), "seed of the pairing randomness")
+	flag.BoolVar(&opts.once, "once", false, "play one round and exit")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "print the jobs instead of publishing them")
+	flag.StringVar(&opts.lane, "lane", "", "runner lane to publish to (see RABBITMQ_LANES), the plain queue when empty")
+	flag.StringVar(&opts.template.Mode, "mode", "", "ALTERNATE (default) or SIMULTANEOUS")
+	flag.StringVar(&opts.template.Map, "map", "", "map of every match, the runner's default when empty")
+	flag.StringVar(&opts.template.Ruleset, "ruleset", "", "ruleset of every match, the runner's default when empty")
+	flag.StringVar(&opts.rulesetsDir, "rulesets", "rulesets", "directory with ruleset files (the runner's RULESETS_PATH), built-in rulesets are used as fallback")
+	flag.IntVar(&opts.template.Games, "games", 0, "play every pairing as a best-of-N series")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(opts options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	publish := func(_ context.Context, job manager.MatchJob) error {
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if !opts.dryRun {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		q, err := queue.NewMatchJobQueue(cfg.MatchJobQueueConfig.Lane(opts.lane))
+		if err != nil {
+			return err
+		}
+		defer q.Close()
+
+		publish = func(ctx context.Context, job manager.MatchJob) error {
+			return q.Publish(ctx, job)
+		}
+	}
+
+	sched := matchmaking.NewScheduler(opts.exploration, opts.maxMatches, opts.window, opts.seed)
+	initial := ratings.DefaultGlicko2().Initial
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	for round := 1; ; round++ {
+		if err := playRound(ctx, opts, sched, initial, round, publish); err != nil {
+			// a broken round is retried with fresh files on the next one
+			log.Println("ROUND FAILED:", err)
+		}
+
+		if opts.once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func playRound(ctx context.Context, opts options, sched *matchmaking.Scheduler, initial float64, round int, publish func(context.Context, manager.MatchJob) error) error {
+	var table *ratings.Table
+	if opts.ratingsPath != "" {
+		t, err := ratings.LoadTable(opts.ratingsPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("load ratings: %w", err)
+		default:
+			table = t
+		}
+	}
+
+	players, err := matchmaking.LoadPlayers(opts.dir, table, initial)
+	if err != nil {
+		return fmt.Errorf("load submissions: %w", err)
+	}
+
+	// pinned now, a ruleset edited while the jobs wait in the queue only
+	// applies to later rounds
+	rules, err := engine.LoadRuleset(opts.rulesetsDir, opts.template.Ruleset)
+	if err != nil {
+		return err
+	}
+	pinned, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("pin ruleset: %w", err)
+	}
+
+	now := time.Now()
+	pairings := sched.Pick(players, opts.perRound, now)
+
+	for i, p := range pairings {
+		job := opts.template
+		job.Rules = pinned
+		job.ID = fmt.Sprintf("mm-%s-r%d-%d", now.Format("20060102-150405"), round, i+1)
+		job.P1, job.P1Code = p.P1.ID, p.P1.Code
+		job.P2, job.P2Code = p.P2.ID, p.P2.Code
+
+		if err := publish(ctx, job); err != nil {
+			return fmt.Errorf("publish %s: %w", job.ID, err)
+		}
+		sched.Book(p, now)
+
+		how := "by rating"
+		if p.Explore {
+			how = "exploring"
+		}
+		log.Printf("round %d: %s %s (%.0f) vs %s (%.0f), %s", round, job.ID, p.P1.ID, p.P1.Rating, p.P2.ID, p.P2.Rating, how)
+	}
+
+	if len(pairings) == 0 {
+		log.Printf("round %d: %d submissions, nobody to pair", round, len(players))
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+// tournament plays a local round-robin league between the submissions in a directory
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/engine"
+	"github.com/delta/code-runner/internal/sandbox"
+)
+
+type submission struct {
+	Name string
+	Dir  string // holds submission.py
+}
+
+// Results is what the results file holds
+type Results struct {
+	Started   time.Time             `json:"started"`
+	Mode      string                `json:"mode"`
+	Map       string                `json:"map"`
+	Ruleset   string                `json:"ruleset"`
+	Games     []*engine.MatchRecord `json:"games"`
+	Standings []*Standing           `json:"standings"`
+}
+
+func main() {
+	dir := flag.String("dir", "submissions", "directory with one <name>.py or <name>/submission.py per team")
+	out := flag.String("out", "tournament", "directory for results.json and the match logs")
+	rounds := flag.Int("rounds", 1, "times every pairing is played on both sides")
+	parallel := flag.Int("parallel", 2, "matches played at once")
+	mode := flag.String("mode", engine.ModeAlternate, "ALTERNATE or SIMULTANEOUS")
+	mapName := flag.String("map", engine.DefaultMapName, "map to play on")
+	mapsDir := flag.String("maps", "maps", "directory with map files, built-in maps are used as fallback")
+	rulesetName := flag.String("ruleset", engine.DefaultRulesetName, "ruleset to play with")
+	rulesetsDir := flag.String("rulesets", "rulesets", "directory with ruleset files, built-in rulesets are used as fallback")
+	jail := flag.Bool("jail", false, "run the submissions in nsjail like the runner (root, runner image) instead of a local python")
+	python := flag.String("python", "python3", "python for unjailed submissions")
+	wrapper := flag.String("wrapper", "wrapper.py", "wrapper for unjailed submissions")
+	flag.Parse()
+
+	if err := run(*dir, *out, *rounds, *parallel, *mode, *mapName, *mapsDir, *rulesetName, *rulesetsDir, *jail, *python, *wrapper); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dir, out string, rounds, parallel int, mode, mapName, mapsDir, rulesetName, rulesetsDir string, jail bool, python, wrapper string) error {
+	if mode != engine.ModeAlternate && mode != engine.ModeSimultaneous {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	if rounds < 1 || parallel < 1 {
+		return errors.New("-rounds and -parallel must be at least 1")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	gameMap, err := engine.LoadMap(mapsDir, mapName)
+	if err != nil {
+		return err
+	}
+
+	rules, err := engine.LoadRuleset(rulesetsDir, rulesetName)
+	if err != nil {
+		return err
+	}
+
+	subs, err := findSubmissions(dir, filepath.Join(out, "submissions"))
+	if err != nil {
+		return err
+	}
+	if len(subs) < 2 {
+		return fmt.Errorf("%s: need at least two submissions, found %d", dir, len(subs))
+	}
+
+	newSandbox, err := sandbox.Local(cfg, jail, python, wrapper)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	results := &Results{
+		Started: started,
+		Mode:    mode,
+		Map:     gameMap.Name,
+		Ruleset: rules.Name,
+		Games:   schedule(subs, rounds, started.Format("20060102-150405")),
+	}
+	dirs := make(map[string]string, len(subs))
+	for _, sub := range subs {
+		dirs[sub.Name] = sub.Dir
+	}
+
+	log.Printf("%d submissions, %d games, %d at once", len(subs), len(results.Games), parallel)
+
+	sem := make(chan struct{}, parallel)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for _, g := range results.Games {
+		sem <- struct{}{} // blocks while parallel games are running
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			play(cfg, g, dirs, filepath.Join(out, "matches"), mode, gameMap, rules, newSandbox)
+
+			mu.Lock()
+			done++
+			log.Printf("[%d/%d] %s", done, len(results.Games), summary(g))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	results.Standings = standings(subs, results.Games)
+	printStandings(os.Stdout, results.Standings)
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	resultsPath := filepath.Join(out, "results.json")
+	if err := os.WriteFile(resultsPath, data, 0644); err != nil {
+		return err
+	}
+	log.Printf("results written to %s, match logs to %s", resultsPath, filepath.Join(out, "matches"))
+
+	return nil
+}
+
+var unsafeIDRe = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// every pairing on both sides, rounds times. IDs start with prefix, so the
+// games of different tournaments can be rated together.
+func schedule(subs []submission, rounds int, prefix string) []*engine.MatchRecord {
+	games := make([]*engine.MatchRecord, 0)
+	for r := range rounds {
+		for i, a := range subs {
+			for j, b := range subs {
+				if i == j {
+					continue
+				}
+				id := fmt.Sprintf("%s-%03d-r%d-%s-vs-%s", prefix, len(games)+1, r+1, a.Name, b.Name)
+				games = append(games, &engine.MatchRecord{
+					ID:     unsafeIDRe.ReplaceAllString(id, "_"),
+					P1:     a.Name,
+					P2:     b.Name,
+					Failed: -1,
+				})
+			}
+		}
+	}
+	return games
+}
+
+// play runs one game the way the runner does, its log goes to <matchesDir>/<id>/log.txt
+func play(cfg *config.Config, g *engine.MatchRecord, dirs map[string]string, matchesDir, mode string, gameMap *engine.GameMap, rules *engine.Ruleset, newSandbox engine.SandboxFunc) {
+	matchDir := filepath.Join(matchesDir, g.ID)
+	if err := os.MkdirAll(matchDir, 0755); err != nil {
+		g.Error = fmt.Sprintf("mkdir: %v", err)
+		return
+	}
+
+	logF, err := os.Create(filepath.Join(matchDir, "log.txt"))
+	if err != nil {
+		g.Error = fmt.Sprintf("create log file: %v", err)
+		return
+	}
+	defer logF.Close()
+
+	gl := engine.NewGameLogger(logF)
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Match ID %s at %s", g.ID, time.Now().Format(time.RFC3339)))
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Map %s", gameMap.Name))
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Ruleset %s", rules.Name), rules)
+
+	m := engine.NewMatch(g.ID, g.P1, g.P2, dirs[g.P1], dirs[g.P2], gl)
+	m.Mode = mode
+	m.Map = gameMap
+	m.Rules = rules
+	m.NewSandbox = newSandbox
+
+	err = m.Simulate(cfg)
+	if err != nil {
+		err = fmt.Errorf("simulate: %w", err)
+		gl.Log(engine.GameLogError, err.Error())
+	}
+	*g = m.Record(err)
+}
+
+func summary(g *engine.MatchRecord) string {
+	switch {
+	case g.Failed != -1:
+		return fmt.Sprintf("%s vs %s: %s failed: %s", g.P1, g.P2, player(g, g.Failed), g.Error)
+	case g.Error != "":
+		return fmt.Sprintf("%s vs %s: error: %s", g.P1, g.P2, g.Error)
+	case g.Result.Winner == engine.Draw:
+		return fmt.Sprintf("%s vs %s: draw (algae %d-%d)", g.P1, g.P2, g.Result.Algae[0], g.Result.Algae[1])
+	default:
+		return fmt.Sprintf("%s vs %s: %s wins (algae %d-%d)", g.P1, g.P2, player(g, g.Result.Winner), g.Result.Algae[0], g.Result.Algae[1])
+	}
+}
+
+func player(g *engine.MatchRecord, playerID int) string {
+	if playerID == engine.PlayerOne {
+		return g.P1
+	}
+	return g.P2
+}
+
+// findSubmissions takes every <name>/submission.py in dir, and copies every
+// <name>.py into its own directory under copyDir
+func findSubmissions(dir, copyDir string) ([]submission, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]submission, 0)
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+
+		if e.IsDir() {
+			if _, err := os.Stat(filepath.Join(path, "submission.py")); err != nil {
+				continue
+			}
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, submission{Name: e.Name(), Dir: abs})
+			continue
+		}
+
+		name, ok := strings.CutSuffix(e.Name(), ".py")
+		if !ok {
+			continue
+		}
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		subDir, err := filepath.Abs(filepath.Join(copyDir, name))
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(subDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(subDir, "submission.py"), code, 0644); err != nil {
+			return nil, err
+		}
+		subs = append(subs, submission{Name: name, Dir: subDir})
+	}
+
+	return subs, nil
+}
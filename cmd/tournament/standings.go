@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// Standing is one line of the table. A submission whose bot fails its
+// handshake or a turn forfeits the game. A game that failed for neither side,
+// or because a sandbox did not start, counts for nobody.
+type Standing struct {
+	Name     string  `json:"name"`
+	Played   int     `json:"played"`
+	Wins     int     `json:"wins"`
+	Draws    int     `json:"draws"`
+	Losses   int     `json:"losses"`
+	Failures int     `json:"failures"`
+	Points   int     `json:"points"`    // 3 per win, 1 per draw
+	AvgAlgae float64 `json:"avg_algae"` // deposited, over the games played to the end
+	algae    int
+	finished int
+}
+
+func standings(subs []submission, games []*engine.MatchRecord) []*Standing {
+	byName := make(map[string]*Standing, len(subs))
+	table := make([]*Standing, 0, len(subs))
+	for _, sub := range subs {
+		st := &Standing{Name: sub.Name}
+		byName[sub.Name] = st
+		table = append(table, st)
+	}
+
+	for _, g := range games {
+		sides := [2]*Standing{byName[g.P1], byName[g.P2]}
+
+		switch {
+		case g.Forfeited():
+			sides[g.Failed].Failures++
+			sides[g.Failed].Losses++
+			sides[1-g.Failed].Wins++
+		case g.Error != "":
+			continue
+		case g.Result.Winner == engine.Draw:
+			sides[0].Draws++
+			sides[1].Draws++
+		default:
+			sides[g.Result.Winner].Wins++
+			sides[1-g.Result.Winner].Losses++
+		}
+
+		for playerID, st := range sides {
+			st.Played++
+			if g.Result != nil && g.Failed == -1 {
+				st.algae += g.Result.Algae[playerID]
+				st.finished++
+			}
+		}
+	}
+
+	for _, st := range table {
+		st.Points = 3*st.Wins + st.Draws
+		if st.finished > 0 {
+			st.AvgAlgae = float64(st.algae) / float64(st.finished)
+		}
+	}
+
+	slices.SortStableFunc(table, func(a, b *Standing) int {
+		if a.Points != b.Points {
+			return b.Points - a.Points
+		}
+		if a.Wins != b.Wins {
+			return b.Wins - a.Wins
+		}
+		switch {
+		case a.AvgAlgae > b.AvgAlgae:
+			return -1
+		case a.AvgAlgae < b.AvgAlgae:
+			return 1
+		}
+		return 0
+	})
+
+	return table
+}
+
+func printStandings(w io.Writer, table []*Standing) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tName\tPlayed\tW\tD\tL\tFailed\tPoints\tAvg algae")
+	for i, st := range table {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.1f\n", i+1, st.Name, st.Played, st.Wins, st.Draws, st.Losses, st.Failures, st.Points, st.AvgAlgae)
+	}
+	tw.Flush()
+}
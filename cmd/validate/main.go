@@ -0,0 +1,105 @@
+// validate smoke tests a submission locally the way a VALIDATE job does
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/engine"
+	"github.com/delta/code-runner/internal/sandbox"
+)
+
+func main() {
+	turns := flag.Int("turns", 0, "turns the submission plays, VALIDATION_TURNS when 0")
+	mode := flag.String("mode", engine.ModeAlternate, "ALTERNATE or SIMULTANEOUS")
+	mapName := flag.String("map", engine.DefaultMapName, "map to play on")
+	mapsDir := flag.String("maps", "maps", "directory with map files, built-in maps are used as fallback")
+	rulesetName := flag.String("ruleset", engine.DefaultRulesetName, "ruleset to play with")
+	rulesetsDir := flag.String("rulesets", "rulesets", "directory with ruleset files, built-in rulesets are used as fallback")
+	seed := flag.Int64("seed", 0, "board seed, random when 0")
+	logPath := flag.String("log", "", "write the game log to this file")
+	jail := flag.Bool("jail", false, "run the submission in nsjail like the runner (root, runner image) instead of a local python")
+	python := flag.String("python", "python3", "python for an unjailed submission")
+	wrapper := flag.String("wrapper", "wrapper.py", "wrapper for an unjailed submission")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] submission.py|dir\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	report, err := run(flag.Arg(0), *turns, *mode, *mapName, *mapsDir, *rulesetName, *rulesetsDir, *seed, *logPath, *jail, *python, *wrapper)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+func run(submission string, turns int, mode, mapName, mapsDir, rulesetName, rulesetsDir string, seed int64, logPath string, jail bool, python, wrapper string) (*engine.ValidationReport, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if turns <= 0 {
+		turns = cfg.ValidationTurns
+	}
+
+	gameMap, err := engine.LoadMap(mapsDir, mapName)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := engine.LoadRuleset(rulesetsDir, rulesetName)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, cleanup, err := sandbox.SubmissionDir(submission)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var logW io.Writer = io.Discard
+	if logPath != "" {
+		f, err := os.Create(logPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		logW = f
+	}
+
+	m := engine.NewMatch("validate", filepath.Base(submission), engine.BuiltinOpponent, dir, "", engine.NewGameLogger(logW))
+	m.Mode = mode
+	m.Map = gameMap
+	m.Rules = rules
+	m.Seed = seed
+
+	m.NewSandbox, err = sandbox.Local(cfg, jail, python, wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Validate(cfg, turns)
+}
@@ -0,0 +1,111 @@
+// viewer serves a browser replay of the match logs in a local submissions directory
+package main
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/delta/code-runner/internal/admin"
+	"github.com/delta/code-runner/internal/engine"
+)
+
+//go:embed static
+var static embed.FS
+
+// match IDs are directory names, never paths
+var matchIDRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type matchInfo struct {
+	ID       string    `json:"id"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+type viewer struct {
+	dir string
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	dir := flag.String("dir", ".submissions", "directory with one <match id>/log.txt per match")
+	flag.Parse()
+
+	v := &viewer{dir: *dir}
+
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(assets))
+	mux.HandleFunc("GET /api/matches", v.listMatches)
+	mux.HandleFunc("GET /api/matches/{id}", v.getMatch)
+
+	log.Printf("Serving replays of %s on http://%s", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (v *viewer) listMatches(w http.ResponseWriter, r *http.Request) {
+	entries, err := os.ReadDir(v.dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	matches := make([]matchInfo, 0)
+	for _, e := range entries {
+		if !e.IsDir() || !matchIDRe.MatchString(e.Name()) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(v.dir, e.Name(), "log.txt"))
+		if err != nil {
+			continue
+		}
+		matches = append(matches, matchInfo{ID: e.Name(), Size: info.Size(), Modified: info.ModTime()})
+	}
+
+	// newest first
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Modified.After(matches[j].Modified)
+	})
+
+	admin.WriteJSON(w, http.StatusOK, matches)
+}
+
+func (v *viewer) getMatch(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !matchIDRe.MatchString(id) {
+		http.Error(w, "invalid match id", http.StatusBadRequest)
+		return
+	}
+
+	f, err := os.Open(filepath.Join(v.dir, id, "log.txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	// deltas are decoded here so the page only ever sees full views
+	replay, err := engine.ReadReplay(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	admin.WriteJSON(w, http.StatusOK, replay)
+}
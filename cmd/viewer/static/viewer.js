@@ -0,0 +1,203 @@
+// replay viewer: the server decodes the log, this only draws full views
+const COLORS = {
+  sea: "#12324a", grid: "#1c415c", wall: "#5a5a5a",
+  algae: "#2fae5a", poison: "#9b3bd1", bank: "#d8b43b", pad: "#3bd8d0",
+  players: ["#e0563b", "#3b8be0"],
+};
+
+let replay = null;
+let current = 0;
+let timer = null;
+
+const $ = (id) => document.getElementById(id);
+
+async function loadMatches() {
+  const res = await fetch("api/matches");
+  const matches = await res.json();
+  const list = $("match-list");
+  list.innerHTML = "";
+  for (const m of matches) {
+    const li = document.createElement("li");
+    li.innerHTML = `${m.id}<br><small>${new Date(m.modified).toLocaleString()} · ${(m.size / 1024).toFixed(0)} KB</small>`;
+    li.onclick = () => openMatch(m.id, li);
+    list.appendChild(li);
+  }
+  if (matches.length === 0) {
+    list.innerHTML = "<li>no match logs</li>";
+  }
+}
+
+async function openMatch(id, li) {
+  stop();
+  document.querySelectorAll("#match-list li").forEach((e) => e.classList.remove("active"));
+  li.classList.add("active");
+  $("title").textContent = `Loading ${id}...`;
+
+  const res = await fetch(`api/matches/${encodeURIComponent(id)}`);
+  if (!res.ok) {
+    $("title").textContent = `${id}: ${await res.text()}`;
+    return;
+  }
+  replay = await res.json();
+  $("title").textContent = id;
+  $("slider").max = Math.max(0, replay.ticks.length - 1);
+  show(0);
+}
+
+function show(i) {
+  if (!replay || replay.ticks.length === 0) {
+    return;
+  }
+  current = Math.max(0, Math.min(i, replay.ticks.length - 1));
+  $("slider").value = current;
+
+  const { view, logs } = replay.ticks[current];
+  $("tick").textContent = `tick ${view.tick} (${current + 1}/${replay.ticks.length})`;
+  $("stats").textContent =
+    `scraps ${view.scraps[0]} / ${view.scraps[1]} · algae banked ${view.algae_count[0]} / ${view.algae_count[1]} · bots ${view.bot_count}/${view.max_bots}`;
+
+  draw(view);
+  showEntities(view);
+  showLogs(logs);
+}
+
+function draw(view) {
+  const canvas = $("canvas");
+  const cell = Math.max(8, Math.floor(640 / Math.max(view.width, view.height)));
+  canvas.width = view.width * cell;
+  canvas.height = view.height * cell;
+  const ctx = canvas.getContext("2d");
+
+  // north is +y, so row 0 is drawn at the bottom
+  const px = (p) => [p.x * cell, (view.height - 1 - p.y) * cell];
+  const fill = (p, color, inset = 0) => {
+    const [x, y] = px(p);
+    ctx.fillStyle = color;
+    ctx.fillRect(x + inset, y + inset, cell - 2 * inset, cell - 2 * inset);
+  };
+  const label = (p, text, color = "#000") => {
+    const [x, y] = px(p);
+    ctx.fillStyle = color;
+    ctx.font = `${Math.floor(cell * 0.45)}px monospace`;
+    ctx.textAlign = "center";
+    ctx.textBaseline = "middle";
+    ctx.fillText(text, x + cell / 2, y + cell / 2);
+  };
+
+  ctx.fillStyle = COLORS.sea;
+  ctx.fillRect(0, 0, canvas.width, canvas.height);
+  ctx.strokeStyle = COLORS.grid;
+  for (let x = 0; x <= view.width; x++) {
+    ctx.beginPath(); ctx.moveTo(x * cell, 0); ctx.lineTo(x * cell, canvas.height); ctx.stroke();
+  }
+  for (let y = 0; y <= view.height; y++) {
+    ctx.beginPath(); ctx.moveTo(0, y * cell); ctx.lineTo(canvas.width, y * cell); ctx.stroke();
+  }
+
+  const pe = view.permanent_entities || {};
+  for (const w of pe.walls || []) {
+    fill(w, COLORS.wall);
+  }
+  for (const a of view.algae || []) {
+    const [x, y] = px(a.location);
+    ctx.fillStyle = a.is_poison === "TRUE" ? COLORS.poison : COLORS.algae;
+    ctx.beginPath();
+    ctx.arc(x + cell / 2, y + cell / 2, cell * 0.2, 0, 2 * Math.PI);
+    ctx.fill();
+  }
+  for (const bank of Object.values(pe.banks || {})) {
+    fill(bank.location, COLORS.bank);
+    ctx.strokeStyle = COLORS.players[bank.bank_owner];
+    ctx.lineWidth = 3;
+    const [x, y] = px(bank.location);
+    ctx.strokeRect(x + 1.5, y + 1.5, cell - 3, cell - 3);
+    ctx.lineWidth = 1;
+    if (bank.lockpick_occuring) {
+      label(bank.location, `L${bank.lockpick_ticks_left}`);
+    } else if (bank.deposit_occuring) {
+      label(bank.location, `D${bank.deposit_ticks_left}`);
+    }
+  }
+  for (const pad of Object.values(pe.energy_pads || {})) {
+    fill(pad.location, pad.available ? COLORS.pad : COLORS.grid);
+    if (!pad.available) {
+      label(pad.location, `${pad.ticks_left}`, COLORS.pad);
+    }
+  }
+  for (const bot of Object.values(view.bots || {})) {
+    fill(bot.location, COLORS.players[bot.owner_id], Math.max(1, cell * 0.12));
+    label(bot.location, bot.algae_held > 0 ? `${bot.algae_held}` : "", "#fff");
+  }
+}
+
+function showEntities(view) {
+  const lines = [];
+  const bots = Object.values(view.bots || {}).sort((a, b) => a.id - b.id);
+  for (const b of bots) {
+    lines.push(`bot ${b.id} P${b.owner_id} (${b.location.x},${b.location.y}) e=${b.energy.toFixed(1)} held=${b.algae_held} ${(b.abilities || []).join(",")}`);
+  }
+  const pe = view.permanent_entities || {};
+  for (const bank of Object.values(pe.banks || {})) {
+    let state = "idle";
+    if (bank.lockpick_occuring) {
+      state = `lockpick by ${bank.lockpick_botid}, ${bank.lockpick_ticks_left} left`;
+    } else if (bank.deposit_occuring) {
+      state = `deposit ${bank.deposit_amount} by P${bank.deposit_owner}, ${bank.deposit_ticks_left} left`;
+    }
+    lines.push(`bank ${bank.id} P${bank.bank_owner} (${bank.location.x},${bank.location.y}) ${state}`);
+  }
+  for (const pad of Object.values(pe.energy_pads || {})) {
+    lines.push(`pad ${pad.id} (${pad.location.x},${pad.location.y}) ${pad.available ? "ready" : `${pad.ticks_left} left`}`);
+  }
+  $("entities").textContent = lines.join("\n");
+}
+
+function showLogs(logs) {
+  const debug = $("show-debug").checked;
+  const list = $("logs");
+  list.innerHTML = "";
+  for (const entry of logs) {
+    if (entry.typ === "DEBUG" && !debug) {
+      continue;
+    }
+    const li = document.createElement("li");
+    li.className = entry.typ;
+    const msg = Array.isArray(entry.msg) && entry.msg.length === 1 ? entry.msg[0] : entry.msg;
+    li.textContent = `${entry.typ} ${typeof msg === "string" ? msg : JSON.stringify(msg)}`;
+    list.appendChild(li);
+  }
+}
+
+function play() {
+  if (timer) {
+    stop();
+    return;
+  }
+  $("play").textContent = "pause";
+  timer = setInterval(() => {
+    if (!replay || current >= replay.ticks.length - 1) {
+      stop();
+      return;
+    }
+    show(current + 1);
+  }, 150);
+}
+
+function stop() {
+  clearInterval(timer);
+  timer = null;
+  $("play").textContent = "play";
+}
+
+$("slider").oninput = (e) => show(Number(e.target.value));
+$("prev").onclick = () => show(current - 1);
+$("next").onclick = () => show(current + 1);
+$("play").onclick = play;
+$("show-debug").onchange = () => show(current);
+document.addEventListener("keydown", (e) => {
+  if (e.key === "ArrowLeft") show(current - 1);
+  if (e.key === "ArrowRight") show(current + 1);
+  if (e.key === " ") { e.preventDefault(); play(); }
+});
+
+loadMatches();
@@ -98,6 +98,9 @@ function printLog(entry) {
     console.log("\x1b[31m[ERROR]\x1b[0m", entry.msg.join(" "), entry.tim);
   } else if (entry.typ === "DEBUG") {
     console.log("\x1b[36m[DEBUG]\x1b[0m", entry.msg.join(" "), entry.tim);
+  } else if (entry.typ === "EVENT") {
+    const ev = entry.msg[0];
+    console.log("\x1b[32m[EVENT]\x1b[0m", ev.type, JSON.stringify(ev.data), entry.tim);
   } else if (entry.typ === "MOVE") {
     console.log("\x1b[36m[MOVE]\x1b[0m", JSON.stringify(entry.msg[0]), entry.tim);
   }
@@ -105,6 +108,33 @@ function printLog(entry) {
 
 /* --------------------------- Replay Parsing --------------------------- */
 
+// VIEW_DELTA entries carry JSON Patch ops against the previous view
+function applyDelta(view, ops) {
+  const next = structuredClone(view);
+
+  for (const op of ops) {
+    const keys = op.path
+      .split("/")
+      .slice(1)
+      .map((k) => k.replace(/~1/g, "/").replace(/~0/g, "~"));
+    const last = keys.pop();
+    const parent = keys.reduce((obj, k) => obj[k], next);
+
+    if (Array.isArray(parent)) {
+      if (op.op === "remove") parent.splice(Number(last), 1);
+      else if (last === "-") parent.push(op.value ?? null);
+      else if (op.op === "add") parent.splice(Number(last), 0, op.value ?? null);
+      else parent[Number(last)] = op.value ?? null;
+    } else if (op.op === "remove") {
+      delete parent[last];
+    } else {
+      parent[last] = op.value ?? null;
+    }
+  }
+
+  return next;
+}
+
 function loadTicks(logPath) {
   const lines = fs.readFileSync(logPath, "utf8").trim().split("\n");
 
@@ -117,10 +147,10 @@ function loadTicks(logPath) {
   for (const line of lines) {
     const entry = JSON.parse(line);
 
-    if (entry.typ === "VIEW") {
+    if (entry.typ === "VIEW" || entry.typ === "VIEW_DELTA") {
       if (current) ticks.push(current);
       current = {
-        view: entry.msg[0],
+        view: entry.typ === "VIEW" ? entry.msg[0] : applyDelta(current.view, entry.msg[0].ops),
         logs: [],
       };
     } else {
@@ -6,6 +6,9 @@ toolchain go1.24.11
 
 require (
 	github.com/rabbitmq/amqp091-go v1.10.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	golang.org/x/sys v0.39.0
 	google.golang.org/protobuf v1.36.10
 )
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
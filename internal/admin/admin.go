@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// Server is a small HTTP server for operating a running runner.
+// It is only started when ADMIN_ADDR is set.
+type Server struct {
+	addr string
+	mux  *http.ServeMux
+}
+
+func NewServer(addr string) *Server {
+	return &Server{
+		addr: addr,
+		mux:  http.NewServeMux(),
+	}
+}
+
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+func (s *Server) HandleFunc(pattern string, h func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, h)
+}
+
+func (s *Server) ListenAndServe() error {
+	log.Printf("admin api listening on %s\n", s.addr)
+	return http.ListenAndServe(s.addr, s.mux)
+}
+
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -1,15 +1,20 @@
 package engine
 
 import (
+	"fmt"
 	"math/rand"
+	"time"
 )
 
 // Stores all information availlable in a game
 type GameEngine struct {
+	Mode           string // ModeAlternate or ModeSimultaneous
 	Ticks          int
 	BotIDSeed      [2]int
 	MaxBots        int
-	Grid           [20][20]Tile
+	Width          int
+	Height         int
+	Grid           [][]Tile      // indexed [x][y]
 	AllBots        map[int]*Bot  //Map of Bot structures, key is its ID
 	Scraps         [2]int        // 0 -> player A, 1 -> player B
 	Banks          map[int]*Bank //key is bankID
@@ -18,6 +23,11 @@ type GameEngine struct {
 	Winner         int
 	AlgaeCount     int
 	Walls          []Point // Added again alongside grid for redundancy and speed
+	Seed           int64   // algae rolls of the board
+	Map            *GameMap
+	Rules          *Ruleset
+	Results        [2][]ActionResult // outcome of each player's last turn
+	events         eventBus
 	gl             *GameLogger
 }
 
@@ -34,31 +44,9 @@ type Bot struct {
 	Status        string   `json:"status"`
 }
 
-var CostDB = map[string]int{
-	"HARVEST":      10,
-	"SCOUT":        10,
-	"SELFDESTRUCT": 5,
-	"LOCKPICK":     5,
-	"SPEEDBOOST":   10,
-	"POISON":       5,
-	"SHIELD":       5,
-}
-
-var EnergyDB = map[string]EnergyCost{
-	"HARVEST":      {0, 1},
-	"SCOUT":        {1.5, 0}, //Pulse mechanic needs be discussed
-	"SELFDESTRUCT": {0.5, 0},
-	"SPEEDBOOST":   {1, 0},
-	"POISON":       {0.5, 2},
-	"LOCKPICK":     {1.5, 0},
-	"SHIELD":       {0.25, 0},
-	"DEPOSIT":      {0, 1},
-	"MOVE":         {0, 0},
-}
-
 type EnergyCost struct {
-	Traversal float64
-	Ability   float64
+	Traversal float64 `json:"traversal"`
+	Ability   float64 `json:"ability"`
 }
 
 type Tile struct {
@@ -98,14 +86,30 @@ type PermanentEntities struct {
 	Walls      []Point      `json:"walls"`
 }
 
-// Starts empty game engine instance
+// Starts empty game engine instance on the default map and ruleset
 func InitGameEngine(gl *GameLogger) *GameEngine {
+	return NewGameEngine(gl, DefaultMap(), DefaultRuleset())
+}
+
+// Starts empty game engine instance on the given map and ruleset
+func NewGameEngine(gl *GameLogger, m *GameMap, rules *Ruleset) *GameEngine {
+	return NewSeededGameEngine(gl, m, rules, time.Now().UnixNano())
+}
+
+// NewSeededGameEngine rolls the same board for the same map and seed
+func NewSeededGameEngine(gl *GameLogger, m *GameMap, rules *Ruleset, seed int64) *GameEngine {
 	ge := &GameEngine{
+		Mode:      ModeAlternate,
 		Ticks:     1,
-		BotIDSeed: [2]int{100, 200},
-		MaxBots:   50,
-		Grid:      [20][20]Tile{},
+		BotIDSeed: [2]int{rules.BotIDs, 2 * rules.BotIDs},
+		MaxBots:   rules.MaxBots,
+		Width:     m.Width,
+		Height:    m.Height,
+		Grid:      newGrid(m.Width, m.Height),
 		Scraps:    [2]int{},
+		Seed:      seed,
+		Map:       m,
+		Rules:     rules,
 
 		AllBots:    make(map[int]*Bot),
 		Banks:      make(map[int]*Bank),
@@ -114,20 +118,30 @@ func InitGameEngine(gl *GameLogger) *GameEngine {
 
 		gl: gl,
 	}
-	ge.Scraps[PlayerOne] = 100
-	ge.Scraps[PlayerTwo] = 100
+	ge.Scraps[PlayerOne] = rules.StartingScraps
+	ge.Scraps[PlayerTwo] = rules.StartingScraps
 
 	ge.initBanks()
 	ge.initPads()
 	ge.generateBoard()
+
+	ge.gl.Log(GameLogDebug, fmt.Sprintf("Board seed %d", seed))
+	ge.gl.Log(GameLogDebug, "Fairness report", ge.FairnessReport())
 	return ge
 }
 
+func newGrid(width, height int) [][]Tile {
+	grid := make([][]Tile, width)
+	for x := range grid {
+		grid[x] = make([]Tile, height)
+	}
+	return grid
+}
+
 func (ge *GameEngine) initBanks() {
-	ge.Banks[1] = initBank(1, 4, 4, PlayerOne)
-	ge.Banks[2] = initBank(2, 15, 4, PlayerTwo)
-	ge.Banks[3] = initBank(3, 4, 15, PlayerOne)
-	ge.Banks[4] = initBank(4, 15, 15, PlayerTwo)
+	for _, b := range ge.Map.Banks {
+		ge.Banks[b.ID] = initBank(b.ID, b.Location.X, b.Location.Y, b.Owner)
+	}
 }
 
 // Need to update Bank structure to have ownership of Banks(can't deposit in enemy bank)
@@ -144,8 +158,9 @@ func initBank(id int, x int, y int, playerID int) *Bank {
 }
 
 func (ge *GameEngine) initPads() {
-	ge.EnergyPads[0] = initPad(1, 9, 8)
-	ge.EnergyPads[1] = initPad(2, 10, 11)
+	for _, p := range ge.Map.EnergyPads {
+		ge.EnergyPads[p.ID] = initPad(p.ID, p.Location.X, p.Location.Y)
+	}
 }
 
 func initPad(id int, x int, y int) *Pad {
@@ -157,23 +172,75 @@ func initPad(id int, x int, y int) *Pad {
 	}
 }
 
-//overhead is negligible due to just 400 tiles. need to choose random tiles if the board size is increased
-
 func (ge *GameEngine) generateBoard() {
-	for x := range BOARDWIDTH {
-		for y := range BOARDHEIGHT {
-			roll := rand.Float64()
-			if ((x == 6 || x == 13) && (y < 6 && y > 2 || y > 13 && y < 17)) || ((y == 6 || y == 13) && (x < 6 && x > 2 || x > 13 && x < 17)) {
-				ge.Grid[x][y].IsWall = true
-				ge.Walls = append(ge.Walls, Point{x, y})
-
-			} else if roll < 0.15 {
-				ge.Grid[x][y].HasAlgae = true
-				ge.AlgaeCount++
-			} else if roll < 0.20 { //5% chance of poison
-				ge.Grid[x][y].HasAlgae = true
-				ge.Grid[x][y].IsPoison = true
+	for _, w := range ge.Map.Walls {
+		ge.Grid[w.X][w.Y].IsWall = true
+		ge.Walls = append(ge.Walls, w)
+	}
+
+	if len(ge.Map.Algae.Fixed) > 0 {
+		for _, t := range ge.Map.Algae.Fixed {
+			ge.placeAlgae(t.Location, t.Poison)
+		}
+		return
+	}
+
+	rng := rand.New(rand.NewSource(ge.Seed))
+
+	// with a symmetry each pair of tiles is rolled once, from its first tile,
+	// and left empty unless both tiles are free
+	for x := range ge.Width {
+		for y := range ge.Height {
+			point := Point{x, y}
+			image := ge.Map.image(point)
+			if image.X < x || (image.X == x && image.Y < y) {
+				continue
+			}
+			if !ge.isFreeTile(point) || !ge.isFreeTile(image) {
+				continue
+			}
+
+			roll := rng.Float64()
+			if roll >= ge.Map.Algae.Density+ge.Map.Algae.PoisonDensity {
+				continue
+			}
+			poison := roll >= ge.Map.Algae.Density //5% chance of poison on the default map
+
+			ge.placeAlgae(point, poison)
+			if image != point {
+				ge.placeAlgae(image, poison)
 			}
 		}
 	}
 }
+
+func (ge *GameEngine) isFreeTile(p Point) bool {
+	return !ge.Grid[p.X][p.Y].IsWall && !ge.isBankTile(p) && !ge.isPadTile(p)
+}
+
+// poisoned algae does not count towards AlgaeCount
+func (ge *GameEngine) placeAlgae(p Point, poison bool) {
+	ge.Grid[p.X][p.Y].HasAlgae = true
+	ge.Grid[p.X][p.Y].IsPoison = poison
+	if !poison {
+		ge.AlgaeCount++
+	}
+}
+
+func (ge *GameEngine) isBankTile(p Point) bool {
+	for _, bank := range ge.Banks {
+		if bank.Location == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (ge *GameEngine) isPadTile(p Point) bool {
+	for _, pad := range ge.EnergyPads {
+		if pad.Location == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/delta/code-runner/internal/sandbox"
+)
+
+// BotPlayer drives a single started sandbox outside of a Match, for tools
+// that seat a submission against something other than a second submission
+type BotPlayer struct {
+	Protocol    Protocol
+	TickTimeout time.Duration
+
+	s *sandbox.Sandbox
+}
+
+// NewBotPlayer handshakes with s the same way Match.Simulate does
+func NewBotPlayer(ctx context.Context, s *sandbox.Sandbox, handshakeTimeoutMS uint32, tickTimeout time.Duration, rules *Ruleset) (*BotPlayer, error) {
+	p, err := handshakeSandbox(ctx, s, handshakeTimeoutMS, rules)
+	if err != nil {
+		return nil, fmt.Errorf("handshake: %w", err)
+	}
+
+	return &BotPlayer{Protocol: p, TickTimeout: tickTimeout, s: s}, nil
+}
+
+// Move sends the view of playerID and waits for the bot's moves, which are
+// in the player's perspective like the view
+func (b *BotPlayer) Move(ctx context.Context, ge *GameEngine, playerID int) (PlayerMoves, error) {
+	turnCtx, cancel := context.WithTimeout(ctx, b.TickTimeout)
+	defer cancel()
+
+	var move PlayerMoves
+
+	if err := b.s.Send(b.Protocol.view(ge.GetPlayerView(playerID))); err != nil {
+		return move, fmt.Errorf("send state: %w", err)
+	}
+
+	if err := b.s.RecvOutput(turnCtx, &move); err != nil {
+		return move, fmt.Errorf("receive actions: %w", err)
+	}
+
+	return move, nil
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+)
+
+// built-in maps and rulesets, a file of the same name in the configured
+// directory overrides them
+//
+//go:embed maps/*.json rulesets/*.json
+var builtins embed.FS
+
+var fileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// readNamed reads <name>.json from dir, falling back to the built-in
+// directory builtinDir. kind only names the file in errors.
+func readNamed(dir, builtinDir, kind, name string) ([]byte, error) {
+	if !fileNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid %s name %q", kind, name)
+	}
+
+	file := name + ".json"
+
+	var (
+		b   []byte
+		err error = fs.ErrNotExist
+	)
+	if dir != "" {
+		b, err = os.ReadFile(path.Join(dir, file))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		b, err = builtins.ReadFile(path.Join(builtinDir, file))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s %q not found", kind, name)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s %q: %w", kind, name, err)
+	}
+
+	return b, nil
+}
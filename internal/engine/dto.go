@@ -33,7 +33,8 @@ type GameViewDTO struct {
 	AlgaeMap          []VisibleAlgaeDTO `json:"algae"`
 }
 
-func (engine *GameEngine) getGameView() GameViewDTO {
+// GameView is the full state of the board, as logged
+func (engine *GameEngine) GameView() GameViewDTO {
 	allBots := make(map[int]Bot, 0)
 	for _, bot := range engine.AllBots {
 		allBots[bot.ID] = *bot
@@ -50,8 +51,8 @@ func (engine *GameEngine) getGameView() GameViewDTO {
 	}
 
 	visibleAlgae := make([]VisibleAlgaeDTO, 0)
-	for x := range BOARDWIDTH {
-		for y := range BOARDHEIGHT {
+	for x := range engine.Width {
+		for y := range engine.Height {
 			tile := engine.Grid[x][y]
 			if tile.HasAlgae {
 				poisonStatus := ""
@@ -76,8 +77,8 @@ func (engine *GameEngine) getGameView() GameViewDTO {
 		Algae:    [2]int{engine.PermanentAlgae[PlayerOne], engine.PermanentAlgae[PlayerTwo]},
 		BotCount: len(engine.AllBots),
 		MaxBots:  engine.MaxBots,
-		Width:    BOARDWIDTH,
-		Height:   BOARDHEIGHT,
+		Width:    engine.Width,
+		Height:   engine.Height,
 		AllBots:  allBots,
 		PermanentEntities: PermanentEntities{
 			Banks:      Banks,
@@ -91,15 +92,18 @@ func (engine *GameEngine) getGameView() GameViewDTO {
 // Sent out to sandbox
 type PlayerViewDTO struct {
 	Tick              int                  `json:"tick"`
+	Mode              string               `json:"mode"`
 	Scraps            int                  `json:"scraps"` //e.g value of Scraps variable will be set to value of scraps in json
 	Algae             int                  `json:"algae"`
 	BotIDSeed         int                  `json:"bot_id_seed"`
+	BotIDMax          int                  `json:"bot_id_max"` // last bot ID the player may spawn
 	MaxBots           int                  `json:"max_bots"`
 	Width             int                  `json:"width"`
 	Height            int                  `json:"height"`
 	Bots              map[int]PlayerBotDTO `json:"bots"` // THINK: this only refers to the bots player's bots right?
 	VisibleEntities   VisibleEntitiesDTO   `json:"visible_entities"`
 	PermanentEntities PermanentEntitiesDTO `json:"permanent_entities"`
+	LastResults       []ActionResult       `json:"last_results"` // outcome of this player's previous turn
 }
 
 type VisibleAlgaeDTO struct {
@@ -193,15 +197,22 @@ func (engine *GameEngine) GetPlayerView(playerID int) PlayerViewDTO {
 		pads[pad.ID] = PadDTO(*pad) // PadDTO is alias of Pad
 	}
 
+	lastResults := engine.Results[playerID]
+	if lastResults == nil {
+		lastResults = make([]ActionResult, 0)
+	}
+
 	// ---- Assemble final view ----
-	return PlayerViewDTO{
+	view := PlayerViewDTO{
 		Tick:      engine.Ticks,
+		Mode:      engine.Mode,
 		BotIDSeed: engine.BotIDSeed[playerID],
+		BotIDMax:  engine.BotIDSeed[playerID] + engine.Rules.BotIDs - 1,
 		Scraps:    engine.Scraps[playerID],
 		Algae:     engine.PermanentAlgae[playerID],
 		MaxBots:   engine.MaxBots,
-		Width:     BOARDWIDTH,
-		Height:    BOARDHEIGHT,
+		Width:     engine.Width,
+		Height:    engine.Height,
 
 		Bots: playerBots,
 
@@ -212,51 +223,22 @@ func (engine *GameEngine) GetPlayerView(playerID int) PlayerViewDTO {
 			EnergyPads: pads,
 			Walls:      engine.Walls,
 		},
+
+		LastResults: lastResults,
 	}
+
+	return engine.perspective(playerID).view(view)
 }
 
 func (engine *GameEngine) calculateVisibleEntities(playerID int) VisibleEntitiesDTO {
 	visibleEnemies := make([]EnemyBotDTO, 0)
 	visibleAlgae := make([]VisibleAlgaeDTO, 0)
 
-	//    canSee := [20][20]bool{}
-	canScout := [BOARDWIDTH][BOARDHEIGHT]bool{}
-
-	for _, bot := range engine.AllBots {
-		if bot.OwnerID == playerID {
-			isScout := false
-			for _, ability := range bot.Abilities {
-				if ability == "SCOUT" {
-					isScout = true
-					break
-				}
-
-				minX := max(0, bot.Location.X-VisionRadius)
-				maxX := min(BOARDWIDTH-1, bot.Location.X+VisionRadius)
-				minY := max(0, bot.Location.Y-VisionRadius)
-				maxY := min(BOARDHEIGHT-1, bot.Location.Y+VisionRadius)
-
-				for x := minX; x <= maxX; x++ {
-					for y := minY; y <= maxY; y++ {
-						dist := manhattanDist(bot.Location.X, bot.Location.Y, x, y)
-
-						// if dist <= VisionRadius {
-						// canSee[x][y] = true // NO MORE VISION
-
-						if isScout && dist <= ScoutRadius {
-							canScout[x][y] = true
-						}
-						//}
-					}
-				}
-			}
-		}
-	}
+	canSee, canScout := engine.visibility(playerID)
 
 	// calculate all enemies in visible region
-	for _, otherBot := range engine.AllBots {
-		if otherBot.OwnerID != playerID {
-			//            if canSee[otherBot.Location.X][otherBot.Location.Y] {
+	for _, otherBot := range engine.sortedBots() {
+		if otherBot.OwnerID != playerID && canSee[otherBot.Location.X][otherBot.Location.Y] {
 			enemy := EnemyBotDTO{
 				ID:        otherBot.ID,
 				Location:  Point{X: otherBot.Location.X, Y: otherBot.Location.Y},
@@ -264,15 +246,14 @@ func (engine *GameEngine) calculateVisibleEntities(playerID int) VisibleEntities
 				Abilities: otherBot.Abilities,
 			}
 			visibleEnemies = append(visibleEnemies, enemy)
-			//            }
 		}
 	}
 
 	// map of all algae in the region
-	for x := range BOARDWIDTH {
-		for y := range BOARDHEIGHT {
+	for x := range engine.Width {
+		for y := range engine.Height {
 			tile := engine.Grid[x][y]
-			if tile.HasAlgae /*&& canSee[x][y]*/ {
+			if tile.HasAlgae && canSee[x][y] {
 				poisonStatus := "UNKNOWN"
 
 				if canScout[x][y] {
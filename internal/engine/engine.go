@@ -6,50 +6,46 @@ import (
     "fmt"
 )
 
-const (
-    TotalTicks        = 1000
-    SpawnEnergy       = 50.0
-    VisionRadius      = 4
-    BaseMovementCost  = 2.0
-    BaseScrapCost     = 10
-    SelfDestructRange = 1
-    BasePadCoolDown   = 50
-    BankDepositTime   = 100
-    BankDepositRange  = 4
-    ScoutRadius       = 4
-    BOARDWIDTH        = 20
-    BOARDHEIGHT       = 20
-    MAXALGAEHELD      = 5
-    LockPickTime      = 20
-)
-
 const (
     PlayerOne = iota
     PlayerTwo
     Draw
 )
 
+
+const (
+    ModeAlternate    = "ALTERNATE"
+    ModeSimultaneous = "SIMULTANEOUS"
+)
+
+// UpdateState applies the moves of the player whose half-turn it is (alternate mode)
 func (engine *GameEngine) UpdateState(move PlayerMoves) {
     playerID := engine.currentPlayerID()
+    move = engine.perspective(playerID).moves(move)
+    engine.Results[playerID] = nil
 
-    for botID, spawnCmd := range move.Spawns {
-    		// TODO: critical, check botID <= engine.BotIDSeed[playerID] + engine.MaxBots[playerID]
-      	if playerID == PlayerTwo {
-       			// correct?
-       			spawnCmd.Location.X = 19
-       	}
-        engine.spawnBot(spawnCmd, playerID, botID)
-    }
-
-    for botID, actionCmd := range move.Actions {
-    		if playerID == PlayerTwo {
-						if actionCmd.Direction == "NORTH" {
-							actionCmd.Direction = "SOUTH"
-						} else if actionCmd.Direction == "SOUTH" {
-							actionCmd.Direction = "NORTH"
-						}
+    for _, botID := range sortedKeys(move.Spawns) {
+        engine.spawnBot(move.Spawns[botID], playerID, botID)
+    }
+
+    // moves are validated one by one, so a bot can take a tile a lower ID just left
+    moved := make([]*plannedAction, 0, len(move.Actions))
+    for _, botID := range sortedKeys(move.Actions) {
+        if a := engine.moveForAction(playerID, botID, move.Actions[botID]); a != nil {
+            moved = append(moved, a)
+        }
+    }
+
+    for _, ability := range engine.Rules.AbilityOrder {
+        for _, a := range moved {
+            if a.cmd.Action == ability {
+                a.code = engine.abilityIfAlive(a)
+            }
         }
-        engine.actionBot(botID, actionCmd)
+    }
+
+    for _, a := range moved {
+        engine.record(playerID, a.botID, ResultAction, true, a.code)
     }
     engine.TickPermanentEntities()
     engine.CheckWinCondition()
@@ -57,20 +53,26 @@ func (engine *GameEngine) UpdateState(move PlayerMoves) {
 }
 
 func (engine *GameEngine) TickPermanentEntities() {
-    for _, bank := range engine.Banks {
+    for _, bankID := range sortedKeys(engine.Banks) {
+        bank := engine.Banks[bankID]
         if bank.LockPickOccuring {
-            if bank.LockPickTicksLeft == 0 {
+            // the picking bot may have died since
+            if engine.getBot(bank.LockPickBotID) == nil {
+                engine.emit(LockpickInterruptedEvent{BotID: bank.LockPickBotID, BankID: bank.ID})
+                bank.LockPickTicksLeft = 0
+                bank.LockPickOccuring = false
+                bank.LockPickBotID = -1
+            } else if bank.LockPickTicksLeft == 0 {
                 bot := engine.getBot(bank.LockPickBotID)
-                engine.gl.Log(GameLogDebug, fmt.Sprintf("Deposit at bankID= %d has been stolen", bank.ID))
+                engine.emit(LockpickSucceededEvent{BotID: bot.ID, BankID: bank.ID, Player: bot.OwnerID})
                 bank.DepositOwner = bot.OwnerID
                 bank.LockPickOccuring = false
                 bank.LockPickBotID = -1
-            }
-            if bank.LockPickTicksLeft > 0 {
+            } else {
                 if isNearBank, _ := engine.isNearBank(bank.LockPickBotID); isNearBank{
                     bank.LockPickTicksLeft--
                 } else {
-                    engine.gl.Log(GameLogDebug, fmt.Sprintf("LockPick at bankID=%d has been stopped", bank.ID))
+                    engine.emit(LockpickInterruptedEvent{BotID: bank.LockPickBotID, BankID: bank.ID})
                     bank.LockPickTicksLeft = 0
                     bank.LockPickOccuring = false
                     bank.LockPickBotID = -1
@@ -80,7 +82,7 @@ func (engine *GameEngine) TickPermanentEntities() {
         if bank.DepositOccuring {
             if bank.DepositTicksLeft == 0 {
                 engine.PermanentAlgae[bank.DepositOwner] += bank.DepositAmount
-                engine.gl.Log(GameLogDebug, fmt.Sprintf("%d Deposited to Player %d at Bank %d", bank.DepositAmount, bank.DepositOwner, bank.ID))
+                engine.emit(DepositCompletedEvent{BankID: bank.ID, Player: bank.DepositOwner, Amount: bank.DepositAmount})
                 bank.DepositAmount = 0
                 bank.DepositOccuring = false
                 bank.DepositOwner = -1
@@ -90,10 +92,11 @@ func (engine *GameEngine) TickPermanentEntities() {
             }
         }
     }
-    for _, EnergyPad := range engine.EnergyPads {
+    for _, padID := range sortedKeys(engine.EnergyPads) {
+        EnergyPad := engine.EnergyPads[padID]
         if EnergyPad.TicksLeft > 0 {
             if EnergyPad.TicksLeft == 1 {
-            		engine.gl.Log(GameLogDebug, fmt.Sprintf("Energy Pad %d replenished", EnergyPad.ID))
+                engine.emit(PadReplenishedEvent{PadID: EnergyPad.ID})
             }
             EnergyPad.TicksLeft--
         }
@@ -112,7 +115,7 @@ func (engine *GameEngine) CheckWinCondition() int {
         engine.gl.Log(GameLogDebug,"Player two has won")
         engine.Winner = PlayerTwo
     }
-    if engine.Ticks >= 1000 {
+    if engine.Ticks >= engine.Rules.TotalTicks {
         if engine.PermanentAlgae[PlayerOne] > engine.PermanentAlgae[PlayerTwo] {
             engine.gl.Log(GameLogDebug,"Player one has won")
             engine.Winner = PlayerOne
@@ -138,56 +141,79 @@ func (engine *GameEngine) currentPlayerID() int {
 }
 
 func (engine *GameEngine) spawnBot(spawn SpawnCmd, playerID int, botID int) bool {
-    if isValid, scrapCost := engine.validateSpawn(spawn, botID); isValid {
+    if code, scrapCost := engine.validateSpawn(spawn, playerID, botID); code == ResultOK {
         bot := Bot{
             ID:            botID,
             OwnerID:       playerID,
             Location:      spawn.Location,
-            Energy:        SpawnEnergy,
+            Energy:        engine.Rules.SpawnEnergy,
             Scraps:        scrapCost,
             Abilities:     spawn.Abilities,
-            VisionRadius:  VisionRadius,
+            VisionRadius:  engine.Rules.VisionRadius,
             TraversalCost: engine.calculateTraversalCost(spawn.Abilities),
         }
         engine.AllBots[bot.ID] = &bot
         engine.Scraps[playerID] -= scrapCost
+        engine.emit(BotSpawnedEvent{BotID: botID, Player: playerID, Location: bot.Location, Abilities: bot.Abilities})
+        engine.record(playerID, botID, ResultSpawn, true, ResultOK)
         return true
     } else {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("Cannot to spawn BotID=%d", botID))
+        engine.record(playerID, botID, ResultSpawn, false, code)
         return false
     }
 }
 
-func (engine *GameEngine) actionBot(botID int, action ActionCmd) {
+// moveForAction spends the energy of an action and moves the bot, its
+// ability is left for the ability phases. Rejected actions are recorded and nil.
+func (engine *GameEngine) moveForAction(playerID int, botID int, action ActionCmd) *plannedAction {
+    code, energyCost := engine.validateMove(playerID, botID, action)
+    if code != ResultOK {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("Cannot to perform action for BotID=%d", botID))
+        engine.record(playerID, botID, ResultAction, false, code)
+        return nil
+    }
+
     bot := engine.getBot(botID)
-    if bot == nil {
-        return
+    bot.Energy -= energyCost
+
+    if !isStay(action.Direction) {
+        engine.moveBot(botID, action.Direction)
     }
-    if validMove, energyCost := engine.validateMove(botID, action); validMove == true {
-        bot.Energy -= energyCost
 
-        if action.Direction != "NIL" {
-            engine.moveBot(botID, action.Direction)
-        }
+    return &plannedAction{playerID: playerID, botID: botID, cmd: action, cost: energyCost, code: ResultOK}
+}
 
-        switch action.Action {
-        case "HARVEST":
-            engine.harvestAlgae(botID)
-        case "SELFDESTRUCT":
-            engine.selfDestructBot(botID)
-        case "POISON":
-            engine.poisonAlgae(botID)
-        case "LOCKPICK":
-            engine.startLockPick(botID)
-        case "DEPOSIT":
-            engine.startDeposit(botID)
-        }
-    } else {
-        engine.gl.Log(GameLogWarn, fmt.Sprintf("Cannot to perform action for BotID=%d", botID))
+// abilityIfAlive performs the ability of a, unless an earlier phase killed the bot
+func (engine *GameEngine) abilityIfAlive(a *plannedAction) ResultCode {
+    if engine.getBot(a.botID) == nil {
+        return ResultNoBot
     }
+    return engine.performAbility(a.playerID, a.botID, a.cmd.Action)
 }
 
-func incrementLocation(loc Point, direction string) (Point, bool) {
+func (engine *GameEngine) performAbility(playerID int, botID int, ability string) ResultCode {
+    switch ability {
+    case "HARVEST":
+        return engine.harvestAlgae(botID)
+    case "SELFDESTRUCT":
+        engine.selfDestructBot(botID)
+    case "POISON":
+        return engine.poisonAlgae(botID)
+    case "LOCKPICK":
+        return engine.startLockPick(botID)
+    case "DEPOSIT":
+        return engine.startDeposit(playerID, botID)
+    }
+    return ResultOK
+}
+
+// both spellings have been used by SDKs for "don't move"
+func isStay(direction string) bool {
+    return direction == "NULL" || direction == "NIL" || direction == ""
+}
+
+func (engine *GameEngine) incrementLocation(loc Point, direction string) (Point, bool) {
     point := loc
     switch direction {
     case "NORTH":
@@ -199,16 +225,26 @@ func incrementLocation(loc Point, direction string) (Point, bool) {
     case "WEST":
         point.X--
     }
-    if point.X < 0 || point.Y < 0 || point.X > 19 || point.Y > 19 {
-        return point, false
-    }
-    return point, true
+    return point, engine.inBounds(point)
 }
 
 func (engine *GameEngine) moveBot(botID int, direction string) {
     bot := engine.getBot(botID)
+    newLocation, isOutOfBounds := engine.destination(bot, direction)
+    if isOutOfBounds {
+        engine.gl.Log(GameLogWarn, "Attempted to move out of bounds", botID)
+    }
+    if newLocation != bot.Location {
+        engine.emit(BotMovedEvent{BotID: botID, From: bot.Location, To: newLocation})
+    }
+    bot.Location = newLocation
+    engine.energyPadCheck(botID)
+}
+
+// destination is where moveBot would put the bot, clamped to the board
+func (engine *GameEngine) destination(bot *Bot, direction string) (Point, bool) {
     newLocation := bot.Location
-    if engine.hasAbility(botID, "SPEEDBOOST") {
+    if engine.hasAbility(bot.ID, "SPEEDBOOST") {
         switch direction {
         case "NORTH":
             newLocation.Y += 2
@@ -237,23 +273,19 @@ func (engine *GameEngine) moveBot(botID int, direction string) {
         isOutOfBounds = true
         newLocation.X = 0
     }
-    if newLocation.X > BOARDWIDTH-1 {
+    if newLocation.X > engine.Width-1 {
         isOutOfBounds = true
-        newLocation.X = BOARDWIDTH-1
+        newLocation.X = engine.Width-1
     }
     if newLocation.Y < 0 {
         isOutOfBounds = true
         newLocation.Y = 0
     }
-    if newLocation.Y > BOARDHEIGHT-1 {
+    if newLocation.Y > engine.Height-1 {
         isOutOfBounds = true
-        newLocation.Y = BOARDHEIGHT-1
-    }
-    if isOutOfBounds {
-        engine.gl.Log(GameLogWarn, "Attempted to move out of bounds", botID)
+        newLocation.Y = engine.Height-1
     }
-    bot.Location = newLocation
-    engine.energyPadCheck(botID)
+    return newLocation, isOutOfBounds
 }
 
 func (engine *GameEngine) energyPadCheck(botID int) {
@@ -263,42 +295,58 @@ func (engine *GameEngine) energyPadCheck(botID int) {
         if pad.Available {
             pad.Available = false
             pad.TicksLeft = engine.getPadCoolDown()
-            bot.Energy = float64(SpawnEnergy)
+            bot.Energy = engine.Rules.SpawnEnergy
+            engine.emit(PadConsumedEvent{BotID: botID, PadID: padID})
         }
     }
 }
 
 func (engine *GameEngine) getPadCoolDown() int {
-    if engine.Ticks < TotalTicks*3/10 {
-        return BasePadCoolDown
+    totalTicks := engine.Rules.TotalTicks
+    baseCoolDown := engine.Rules.BasePadCoolDown
+    if engine.Ticks < totalTicks*3/10 {
+        return baseCoolDown
     }
-    if engine.Ticks < TotalTicks*5/10 {
-        return BasePadCoolDown * 5 / 10
+    if engine.Ticks < totalTicks*5/10 {
+        return baseCoolDown * 5 / 10
     }
-    if engine.Ticks < TotalTicks*7/10 {
-        return BasePadCoolDown * 1 / 4
+    if engine.Ticks < totalTicks*7/10 {
+        return baseCoolDown * 1 / 4
     }
-    return BasePadCoolDown * 2 / 10
+    return baseCoolDown * 2 / 10
 }
 
 func (engine *GameEngine) selfDestructBot(botID int) {
     bot := engine.getBot(botID)
-    for _, botB := range engine.AllBots {
-        if math.Abs(float64(bot.Location.X-botB.Location.X)) <= SelfDestructRange && math.Abs(float64(bot.Location.Y-botB.Location.Y)) <= SelfDestructRange {
+    for _, botB := range engine.sortedBots() {
+        if botB.ID == botID {
+            continue
+        }
+        if math.Abs(float64(bot.Location.X-botB.Location.X)) <= float64(engine.Rules.SelfDestructRange) && math.Abs(float64(bot.Location.Y-botB.Location.Y)) <= float64(engine.Rules.SelfDestructRange) {
             if engine.hasAbility(botB.ID, "SHIELD") {
                 engine.removeShield(botB.ID)
             } else {
-                engine.KillBot(botB.ID)
+                engine.killBot(botB.ID, KillCauseBlast, botID)
             }
         }
     }
-    engine.KillBot(bot.ID)
+    engine.killBot(bot.ID, KillCauseSelfDestruct, -1)
 }
 
 func (engine *GameEngine) KillBot(botID int) {
     delete(engine.AllBots, botID)
 }
 
+// killBot removes the bot and emits why; byBotID is the blasting bot or -1
+func (engine *GameEngine) killBot(botID int, cause string, byBotID int) {
+    bot := engine.getBot(botID)
+    if bot == nil {
+        return
+    }
+    engine.emit(BotKilledEvent{BotID: botID, Player: bot.OwnerID, Location: bot.Location, Cause: cause, ByBotID: byBotID})
+    engine.KillBot(botID)
+}
+
 func (engine *GameEngine) removeShield(botID int) {
     bot := engine.getBot(botID)
     newAbilities := make([]string, 0, len(bot.Abilities)-1)
@@ -307,36 +355,95 @@ func (engine *GameEngine) removeShield(botID int) {
             newAbilities = append(newAbilities, ability)
         }
     }
-    bot.TraversalCost -= EnergyDB["SHIELD"].Traversal
+    bot.TraversalCost -= engine.Rules.Energy["SHIELD"].Traversal
     bot.Abilities = newAbilities
+    engine.emit(ShieldBrokenEvent{BotID: botID})
 }
 
-func (engine *GameEngine) validateSpawn(spawn SpawnCmd, botID int) (bool, int) {
+func (engine *GameEngine) validateSpawn(spawn SpawnCmd, playerID int, botID int) (ResultCode, int) {
     scrapCost := 0
-    playerID := engine.currentPlayerID()
+    if botID < engine.BotIDSeed[playerID] || botID >= engine.BotIDSeed[playerID]+engine.Rules.BotIDs {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d is outside the range %d..%d of player %d", botID, engine.BotIDSeed[playerID], engine.BotIDSeed[playerID]+engine.Rules.BotIDs-1, playerID))
+        return ResultBadBotID, scrapCost
+    }
+
     bot := engine.getBot(botID)
     if bot != nil {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d already exists", botID))
-        return false, scrapCost
+        return ResultBotExists, scrapCost
+    }
+
+    if engine.botCount(playerID) >= engine.MaxBots {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d would exceed the %d bots of player %d", botID, engine.MaxBots, playerID))
+        return ResultMaxBots, scrapCost
+    }
+
+    if !engine.inBounds(spawn.Location) {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn out of bounds at (%d %d)", botID, spawn.Location.X, spawn.Location.Y))
+        return ResultOutOfBounds, scrapCost
     }
 
     if engine.LocationOccupied(spawn.Location) {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn at occupied location", botID))
-        return false, scrapCost
+        return ResultOccupied, scrapCost
+    }
+
+    if engine.isWall(spawn.Location) {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn on a wall at (%d %d)", botID, spawn.Location.X, spawn.Location.Y))
+        return ResultWall, scrapCost
+    }
+
+    if engine.isBankTile(spawn.Location) {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn on a bank at (%d %d)", botID, spawn.Location.X, spawn.Location.Y))
+        return ResultOnBank, scrapCost
+    }
+
+    if engine.isPadTile(spawn.Location) {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn on an energy pad at (%d %d)", botID, spawn.Location.X, spawn.Location.Y))
+        return ResultOnPad, scrapCost
+    }
+
+    if !engine.inSpawnZone(playerID, spawn.Location) {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn outside the spawn zone at (%d %d)", botID, spawn.Location.X, spawn.Location.Y))
+        return ResultNotSpawnZone, scrapCost
     }
 
     for _, ability := range spawn.Abilities {
-        scrapCost += CostDB[ability]
+        cost, ok := engine.Rules.Costs[ability]
+        if !ok {
+            engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d attempted spawn with unknown ability=%s", botID, ability))
+            return ResultNoAbility, scrapCost
+        }
+        scrapCost += cost
     }
 
     if scrapCost > engine.Scraps[playerID] {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d does not have enough scraps to spawn", botID))
-        return false, scrapCost
+        return ResultNoScraps, scrapCost
     }
-    return true, scrapCost
+    return ResultOK, scrapCost
 
 }
 
+func (engine *GameEngine) botCount(playerID int) int {
+    n := 0
+    for _, bot := range engine.AllBots {
+        if bot.OwnerID == playerID {
+            n++
+        }
+    }
+    return n
+}
+
+func (engine *GameEngine) inSpawnZone(playerID int, point Point) bool {
+    for _, zone := range engine.Map.SpawnZones {
+        if zone.Owner == playerID && zone.Contains(point) {
+            return true
+        }
+    }
+    return false
+}
+
 func (engine *GameEngine) LocationOccupied(point Point) bool {
     // TODO: What about other factors like banks ?
     for _, bot := range engine.AllBots {
@@ -347,130 +454,148 @@ func (engine *GameEngine) LocationOccupied(point Point) bool {
     return false
 }
 
-func (engine *GameEngine) validateMove(botID int, move ActionCmd) (bool, float64) {
-    playerID := engine.currentPlayerID()
+func (engine *GameEngine) validateMove(playerID int, botID int, move ActionCmd) (ResultCode, float64) {
     bot := engine.getBot(botID)
     energyCost := 0.0
     if bot == nil {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("Invalid BotID %d", botID))
-        return false, energyCost
+        return ResultNoBot, energyCost
     }
     if bot.OwnerID != playerID {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("Player %d attempted to control invalid Bot %d", playerID, botID))
-        return false, energyCost
+        return ResultNotOwner, energyCost
     }
 
-    if move.Direction != "NULL" {
-        point, ok := incrementLocation(bot.Location, move.Direction)
+    if !isStay(move.Direction) {
+        point, ok := engine.incrementLocation(bot.Location, move.Direction)
         if !ok {
             engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to move out of bounds at (%d %d)", botID, point.X, point.Y))
-            return false, energyCost
+            return ResultOutOfBounds, energyCost
         }
         if engine.LocationOccupied(point) {
             engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to move at Occupied Location at (%d %d)", botID, point.X, point.Y))
-            return false, energyCost
+            return ResultOccupied, energyCost
         }
         if engine.isWall(point){
             engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to move to a wall at (%d %d)", botID, point.X, point.Y))
-            return false, energyCost
+            return ResultWall, energyCost
         }
         energyCost += bot.TraversalCost
     }
     if move.Action != "MOVE"{
         if !engine.hasAbility(botID, move.Action) {
             engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d does not have ability=%s", botID, move.Action))
-            return false, energyCost
+            return ResultNoAbility, energyCost
         }
     }
 
-    energyCost += EnergyDB[move.Action].Ability
+    energyCost += engine.Rules.Energy[move.Action].Ability
 
     if energyCost > bot.Energy {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d does not have enough energy for ability=%s", botID, move.Action))
-        return false, energyCost
+        return ResultNoEnergy, energyCost
     }
 
-    return true, energyCost
+    return ResultOK, energyCost
 }
 
 func (engine *GameEngine) calculateTraversalCost(Abilities []string) float64 {
-    energyCost := float64(BaseMovementCost)
+    energyCost := engine.Rules.BaseMovementCost
 
     for _, ability := range Abilities {
-        energyCost += EnergyDB[ability].Traversal
+        energyCost += engine.Rules.Energy[ability].Traversal
     }
     return energyCost
 }
 
-func (engine *GameEngine) harvestAlgae(botID int) {
+func (engine *GameEngine) harvestAlgae(botID int) ResultCode {
     bot := engine.getBot(botID)
-    if bot.AlgaeHeld > MAXALGAEHELD {
-        return
+    if bot.AlgaeHeld > engine.Rules.MaxAlgaeHeld {
+        return ResultFull
     }
     if engine.isAlgae(bot.Location) {
-        if engine.isPoison(bot.Location) {
-            engine.gl.Log(GameLogDebug, fmt.Sprintf("botID=%d has harvested a poisonous algae", botID))
-            engine.KillBot(botID)
+        poison := engine.isPoison(bot.Location)
+        engine.emit(AlgaeHarvestedEvent{BotID: botID, Location: bot.Location, Poison: poison})
+        if poison {
+            engine.killBot(botID, KillCausePoison, -1)
         }
         engine.Grid[bot.Location.X][bot.Location.Y].HasAlgae = false
         engine.Grid[bot.Location.X][bot.Location.Y].IsPoison = false
         bot.AlgaeHeld += 1
-        bot.Energy -= EnergyDB["HARVEST"].Ability
+        bot.Energy -= engine.Rules.Energy["HARVEST"].Ability
+        return ResultOK
     } else {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to harvest empty location", botID))
+        return ResultNoAlgae
     }
 }
 
-func (engine *GameEngine) poisonAlgae(botID int) {
+func (engine *GameEngine) poisonAlgae(botID int) ResultCode {
     bot := engine.getBot(botID)
+    bot.Energy -= engine.Rules.Energy["POISON"].Ability
     if engine.isAlgae(bot.Location) {
         engine.Grid[bot.Location.X][bot.Location.Y].IsPoison = true
         engine.AlgaeCount--
-        engine.gl.Log(GameLogDebug, fmt.Sprintf("botID=%d has poisoned algae at (%d %d)", botID, bot.Location.X, bot.Location.Y))
+        engine.emit(AlgaePoisonedEvent{BotID: botID, Location: bot.Location})
+        return ResultOK
     } else {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to poison empty location", botID))
+        return ResultNoAlgae
     }
-    bot.Energy -= EnergyDB["POISON"].Ability
 }
 
-func (engine *GameEngine) startLockPick(botID int) {
+func (engine *GameEngine) startLockPick(botID int) ResultCode {
     bot := engine.getBot(botID)
     if NearBank, bankID := engine.isNearBank(botID); NearBank {
         engine.Banks[bankID].LockPickOccuring = true
-        engine.Banks[bankID].LockPickTicksLeft = LockPickTime
+        engine.Banks[bankID].LockPickTicksLeft = engine.Rules.LockPickTime
         engine.Banks[bankID].LockPickBotID = botID
-        bot.Energy -= EnergyDB["LOCKPICK"].Ability
+        bot.Energy -= engine.Rules.Energy["LOCKPICK"].Ability
+        engine.emit(LockpickStartedEvent{BotID: botID, BankID: bankID})
+        return ResultOK
     } else {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to LockPick too far from a bank", botID))
+        return ResultNotNearBank
     }
 }
 
-func (engine *GameEngine) startDeposit(botID int) {
+func (engine *GameEngine) startDeposit(playerID int, botID int) ResultCode {
     bot := engine.getBot(botID)
-    playerID := engine.currentPlayerID()
-    if isNearBank, bankID := engine.isNearBank(botID); isNearBank {
-        bank := engine.Banks[bankID]
-        if bank.BankOwner == playerID && bank.DepositOccuring == false && bot.AlgaeHeld > 0 {
-            fmt.Printf("Entered here")
-            bank.DepositOwner = playerID
-            bank.DepositTicksLeft = BankDepositTime
-            bank.DepositOccuring = true
-            bank.DepositAmount = bot.AlgaeHeld
-            bot.AlgaeHeld = 0
-            bot.Energy -= EnergyDB["DEPOSIT"].Ability
-            engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to deposit at bank already undergoing a deposit", botID))
-        } else {
-            engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to deposit at bank not owned by them", botID))
-        }
-    } else {
+    isNearBank, bankID := engine.isNearBank(botID)
+    if !isNearBank {
         engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to deposit too far from a bank", botID))
+        return ResultNotNearBank
+    }
+
+    bank := engine.Banks[bankID]
+    if bank.BankOwner != playerID {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to deposit at bank not owned by them", botID))
+        return ResultNotBankOwner
     }
+    if bank.DepositOccuring {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to deposit at bank already undergoing a deposit", botID))
+        return ResultBankBusy
+    }
+    if bot.AlgaeHeld == 0 {
+        engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to deposit without holding algae", botID))
+        return ResultNothingHeld
+    }
+
+    bank.DepositOwner = playerID
+    bank.DepositTicksLeft = engine.Rules.BankDepositTime
+    bank.DepositOccuring = true
+    bank.DepositAmount = bot.AlgaeHeld
+    engine.emit(DepositStartedEvent{BotID: botID, BankID: bankID, Player: playerID, Amount: bot.AlgaeHeld})
+    bot.AlgaeHeld = 0
+    bot.Energy -= engine.Rules.Energy["DEPOSIT"].Ability
+    return ResultOK
 }
 
 func (engine *GameEngine) isNearBank(botID int) (bool, int) {
     bot := engine.getBot(botID)
-    for bankID, bank := range engine.Banks {
-        if math.Abs(float64(bot.Location.X-bank.Location.X)) <= BankDepositRange && math.Abs(float64(bot.Location.Y-bank.Location.Y)) <= BankDepositRange {
+    for _, bankID := range sortedKeys(engine.Banks) {
+        bank := engine.Banks[bankID]
+        if math.Abs(float64(bot.Location.X-bank.Location.X)) <= float64(engine.Rules.BankDepositRange) && math.Abs(float64(bot.Location.Y-bank.Location.Y)) <= float64(engine.Rules.BankDepositRange) {
             return true, bankID
         }
     }
@@ -487,6 +612,10 @@ func (engine *GameEngine) isOnEnergyPad(botID int) (bool, int) {
     return false, -1
 }
 
+func (engine *GameEngine) inBounds(loc Point) bool {
+    return loc.X >= 0 && loc.Y >= 0 && loc.X < engine.Width && loc.Y < engine.Height
+}
+
 func (engine *GameEngine) isPoison(loc Point) bool {
     return engine.Grid[loc.X][loc.Y].IsPoison
 }
@@ -0,0 +1,183 @@
+package engine
+
+import "sync"
+
+// Logged for every event the engine emits
+const GameLogEvent GameLogType = "EVENT"
+
+type EventType string
+
+const (
+	EventBotSpawned          EventType = "BOT_SPAWNED"
+	EventBotMoved            EventType = "BOT_MOVED"
+	EventBotKilled           EventType = "BOT_KILLED"
+	EventAlgaeHarvested      EventType = "ALGAE_HARVESTED"
+	EventAlgaePoisoned       EventType = "ALGAE_POISONED"
+	EventDepositStarted      EventType = "DEPOSIT_STARTED"
+	EventDepositCompleted    EventType = "DEPOSIT_COMPLETED"
+	EventLockpickStarted     EventType = "LOCKPICK_STARTED"
+	EventLockpickInterrupted EventType = "LOCKPICK_INTERRUPTED"
+	EventLockpickSucceeded   EventType = "LOCKPICK_SUCCEEDED"
+	EventPadConsumed         EventType = "PAD_CONSUMED"
+	EventPadReplenished      EventType = "PAD_REPLENISHED"
+	EventShieldBroken        EventType = "SHIELD_BROKEN"
+)
+
+// Why a bot was removed from the board
+const (
+	KillCausePoison       = "POISON"       // harvested poisoned algae
+	KillCauseSelfDestruct = "SELFDESTRUCT" // blew itself up
+	KillCauseBlast        = "BLAST"        // caught in another bot's self-destruct
+)
+
+// Event is one of the *Event structs below
+type Event interface {
+	EventType() EventType
+}
+
+// GameEvent is how an event is logged and handed to subscribers
+type GameEvent struct {
+	Type  EventType `json:"type"`
+	Tick  int       `json:"tick"`
+	Event Event     `json:"data"`
+}
+
+type BotSpawnedEvent struct {
+	BotID     int      `json:"bot_id"`
+	Player    int      `json:"player"`
+	Location  Point    `json:"location"`
+	Abilities []string `json:"abilities"`
+}
+
+type BotMovedEvent struct {
+	BotID int   `json:"bot_id"`
+	From  Point `json:"from"`
+	To    Point `json:"to"`
+}
+
+type BotKilledEvent struct {
+	BotID    int    `json:"bot_id"`
+	Player   int    `json:"player"`
+	Location Point  `json:"location"`
+	Cause    string `json:"cause"`  // one of the KillCause* constants
+	ByBotID  int    `json:"by_bot"` // self-destructing bot for KillCauseBlast, else -1
+}
+
+type AlgaeHarvestedEvent struct {
+	BotID    int   `json:"bot_id"`
+	Location Point `json:"location"`
+	Poison   bool  `json:"poison"`
+}
+
+type AlgaePoisonedEvent struct {
+	BotID    int   `json:"bot_id"`
+	Location Point `json:"location"`
+}
+
+type DepositStartedEvent struct {
+	BotID  int `json:"bot_id"`
+	BankID int `json:"bank_id"`
+	Player int `json:"player"`
+	Amount int `json:"amount"`
+}
+
+type DepositCompletedEvent struct {
+	BankID int `json:"bank_id"`
+	Player int `json:"player"` // who the algae was credited to
+	Amount int `json:"amount"`
+}
+
+type LockpickStartedEvent struct {
+	BotID  int `json:"bot_id"`
+	BankID int `json:"bank_id"`
+}
+
+type LockpickInterruptedEvent struct {
+	BotID  int `json:"bot_id"`
+	BankID int `json:"bank_id"`
+}
+
+type LockpickSucceededEvent struct {
+	BotID  int `json:"bot_id"`
+	BankID int `json:"bank_id"`
+	Player int `json:"player"` // new owner of the running deposit
+}
+
+type PadConsumedEvent struct {
+	BotID int `json:"bot_id"`
+	PadID int `json:"pad_id"`
+}
+
+type PadReplenishedEvent struct {
+	PadID int `json:"pad_id"`
+}
+
+type ShieldBrokenEvent struct {
+	BotID int `json:"bot_id"`
+}
+
+func (BotSpawnedEvent) EventType() EventType          { return EventBotSpawned }
+func (BotMovedEvent) EventType() EventType            { return EventBotMoved }
+func (BotKilledEvent) EventType() EventType           { return EventBotKilled }
+func (AlgaeHarvestedEvent) EventType() EventType      { return EventAlgaeHarvested }
+func (AlgaePoisonedEvent) EventType() EventType       { return EventAlgaePoisoned }
+func (DepositStartedEvent) EventType() EventType      { return EventDepositStarted }
+func (DepositCompletedEvent) EventType() EventType    { return EventDepositCompleted }
+func (LockpickStartedEvent) EventType() EventType     { return EventLockpickStarted }
+func (LockpickInterruptedEvent) EventType() EventType { return EventLockpickInterrupted }
+func (LockpickSucceededEvent) EventType() EventType   { return EventLockpickSucceeded }
+func (PadConsumedEvent) EventType() EventType         { return EventPadConsumed }
+func (PadReplenishedEvent) EventType() EventType      { return EventPadReplenished }
+func (ShieldBrokenEvent) EventType() EventType        { return EventShieldBroken }
+
+type eventBus struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]func(GameEvent)
+}
+
+// Subscribe calls fn with every event the engine emits from now on, until
+// the returned func is called. fn runs on the goroutine updating the
+// engine, in emit order, and must not block.
+func (engine *GameEngine) Subscribe(fn func(GameEvent)) (unsubscribe func()) {
+	bus := &engine.events
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.subs == nil {
+		bus.subs = make(map[int]func(GameEvent))
+	}
+	id := bus.next
+	bus.next++
+	bus.subs[id] = fn
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		delete(bus.subs, id)
+	}
+}
+
+func (engine *GameEngine) emit(ev Event) {
+	ge := GameEvent{
+		Type:  ev.EventType(),
+		Tick:  engine.Ticks,
+		Event: ev,
+	}
+
+	engine.gl.Log(GameLogEvent, ge)
+
+	bus := &engine.events
+	bus.mu.Lock()
+	subs := make([]func(GameEvent), 0, len(bus.subs))
+	for id := 0; id < bus.next; id++ {
+		if fn, ok := bus.subs[id]; ok {
+			subs = append(subs, fn)
+		}
+	}
+	bus.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(ge)
+	}
+}
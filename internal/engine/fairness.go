@@ -0,0 +1,84 @@
+package engine
+
+import "math"
+
+// FairnessReport compares the starting algae of both players, logged at match start
+type FairnessReport struct {
+	Map      string            `json:"map"`
+	Symmetry string            `json:"symmetry"`
+	Players  [2]PlayerFairness `json:"players"`
+}
+
+type PlayerFairness struct {
+	// tiles strictly closer to this player's banks than to the opponent's
+	Algae  int `json:"algae"`
+	Poison int `json:"poison"`
+	// mean distance from every healthy algae tile to this player's nearest bank
+	AvgBankDistance float64 `json:"avg_bank_distance"`
+}
+
+func (engine *GameEngine) FairnessReport() FairnessReport {
+	report := FairnessReport{
+		Map:      engine.Map.Name,
+		Symmetry: engine.Map.Algae.Symmetry,
+	}
+	if report.Symmetry == "" {
+		report.Symmetry = SymmetryNone
+	}
+
+	var (
+		totalDist [2]int
+		healthy   int
+	)
+
+	for x := range engine.Width {
+		for y := range engine.Height {
+			tile := engine.Grid[x][y]
+			if !tile.HasAlgae {
+				continue
+			}
+
+			p := Point{x, y}
+			dist := [2]int{engine.nearestBankDist(p, PlayerOne), engine.nearestBankDist(p, PlayerTwo)}
+
+			closer := -1
+			if dist[PlayerOne] < dist[PlayerTwo] {
+				closer = PlayerOne
+			} else if dist[PlayerTwo] < dist[PlayerOne] {
+				closer = PlayerTwo
+			}
+
+			if tile.IsPoison {
+				if closer != -1 {
+					report.Players[closer].Poison++
+				}
+				continue
+			}
+
+			if closer != -1 {
+				report.Players[closer].Algae++
+			}
+			healthy++
+			totalDist[PlayerOne] += dist[PlayerOne]
+			totalDist[PlayerTwo] += dist[PlayerTwo]
+		}
+	}
+
+	if healthy > 0 {
+		for playerID := range report.Players {
+			report.Players[playerID].AvgBankDistance = float64(totalDist[playerID]) / float64(healthy)
+		}
+	}
+
+	return report
+}
+
+func (engine *GameEngine) nearestBankDist(p Point, playerID int) int {
+	best := math.MaxInt
+	for _, bank := range engine.Banks {
+		if bank.BankOwner == playerID {
+			best = min(best, manhattanDist(p.X, p.Y, bank.Location.X, bank.Location.Y))
+		}
+	}
+	return best
+}
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const DefaultMapName = "default"
+
+const (
+	MinBoardSize = 5
+	MaxBoardSize = 100
+)
+
+// GameMap is the board layout a match is played on, loaded from a map file
+type GameMap struct {
+	Name       string      `json:"name"`
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Walls      []Point     `json:"walls"`
+	Banks      []MapBank   `json:"banks"`
+	EnergyPads []MapPad    `json:"energy_pads"`
+	SpawnZones []SpawnZone `json:"spawn_zones"`
+	Algae      MapAlgae    `json:"algae"`
+}
+
+type MapBank struct {
+	ID       int   `json:"id"`
+	Location Point `json:"location"`
+	Owner    int   `json:"owner"` // 0 = player A, 1 = player B
+}
+
+type MapPad struct {
+	ID       int   `json:"id"`
+	Location Point `json:"location"`
+}
+
+// SpawnZone is an inclusive rectangle a player may spawn bots in
+type SpawnZone struct {
+	Owner int   `json:"owner"`
+	Min   Point `json:"min"`
+	Max   Point `json:"max"`
+}
+
+func (z SpawnZone) Contains(p Point) bool {
+	return p.X >= z.Min.X && p.X <= z.Max.X && p.Y >= z.Min.Y && p.Y <= z.Max.Y
+}
+
+// MapAlgae places algae either at fixed tiles, or when Fixed is empty,
+// by rolling every free tile against the densities
+type MapAlgae struct {
+	Density       float64        `json:"density"`
+	PoisonDensity float64        `json:"poison_density"`
+	Symmetry      string         `json:"symmetry,omitempty"` // one of the Symmetry* constants
+	Fixed         []MapAlgaeTile `json:"fixed,omitempty"`
+}
+
+// Random algae layouts. Players sit on the left and right of the board,
+// so a symmetric layout gives both sides the same algae and poison.
+const (
+	SymmetryNone   = "none"   // every tile rolled independently
+	SymmetryMirror = "mirror" // (x, y) matches (width-1-x, y)
+	SymmetryPoint  = "point"  // (x, y) matches (width-1-x, height-1-y)
+)
+
+type MapAlgaeTile struct {
+	Location Point `json:"location"`
+	Poison   bool  `json:"poison"`
+}
+
+// LoadMap reads the map called name from dir, falling back to the built-in maps
+func LoadMap(dir string, name string) (*GameMap, error) {
+	if name == "" {
+		name = DefaultMapName
+	}
+
+	b, err := readNamed(dir, "maps", "map", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseMap(b)
+}
+
+func ParseMap(b []byte) (*GameMap, error) {
+	var m GameMap
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse map: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("map %q: %w", m.Name, err)
+	}
+
+	return &m, nil
+}
+
+// DefaultMap is the layout matches used before map files existed
+func DefaultMap() *GameMap {
+	m, err := LoadMap("", DefaultMapName)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (m *GameMap) Validate() error {
+	if m.Name == "" {
+		return errors.New("missing name")
+	}
+
+	if m.Width < MinBoardSize || m.Height < MinBoardSize || m.Width > MaxBoardSize || m.Height > MaxBoardSize {
+		return fmt.Errorf("board sides must be within %d..%d, got %dx%d", MinBoardSize, MaxBoardSize, m.Width, m.Height)
+	}
+
+	// every wall, bank and pad needs a tile of its own
+	taken := make(map[Point]string)
+	claim := func(p Point, what string) error {
+		if !m.inBounds(p) {
+			return fmt.Errorf("%s at (%d %d) is out of bounds", what, p.X, p.Y)
+		}
+		if other, ok := taken[p]; ok {
+			return fmt.Errorf("%s at (%d %d) overlaps %s", what, p.X, p.Y, other)
+		}
+		taken[p] = what
+		return nil
+	}
+
+	for _, w := range m.Walls {
+		if err := claim(w, "wall"); err != nil {
+			return err
+		}
+	}
+
+	bankIDs := make(map[int]bool)
+	var banksPerPlayer [2]int
+	for _, b := range m.Banks {
+		if bankIDs[b.ID] {
+			return fmt.Errorf("duplicate bank id %d", b.ID)
+		}
+		bankIDs[b.ID] = true
+
+		if b.Owner != PlayerOne && b.Owner != PlayerTwo {
+			return fmt.Errorf("bank %d has invalid owner %d", b.ID, b.Owner)
+		}
+		banksPerPlayer[b.Owner]++
+
+		if err := claim(b.Location, fmt.Sprintf("bank %d", b.ID)); err != nil {
+			return err
+		}
+	}
+	if banksPerPlayer[PlayerOne] == 0 || banksPerPlayer[PlayerTwo] == 0 {
+		return errors.New("each player needs at least one bank")
+	}
+
+	padIDs := make(map[int]bool)
+	for _, p := range m.EnergyPads {
+		if padIDs[p.ID] {
+			return fmt.Errorf("duplicate energy pad id %d", p.ID)
+		}
+		padIDs[p.ID] = true
+
+		if err := claim(p.Location, fmt.Sprintf("energy pad %d", p.ID)); err != nil {
+			return err
+		}
+	}
+
+	var zonesPerPlayer [2]int
+	for _, z := range m.SpawnZones {
+		if z.Owner != PlayerOne && z.Owner != PlayerTwo {
+			return fmt.Errorf("spawn zone has invalid owner %d", z.Owner)
+		}
+		if !m.inBounds(z.Min) || !m.inBounds(z.Max) || z.Min.X > z.Max.X || z.Min.Y > z.Max.Y {
+			return fmt.Errorf("invalid spawn zone (%d %d)-(%d %d)", z.Min.X, z.Min.Y, z.Max.X, z.Max.Y)
+		}
+		zonesPerPlayer[z.Owner]++
+	}
+	if zonesPerPlayer[PlayerOne] == 0 || zonesPerPlayer[PlayerTwo] == 0 {
+		return errors.New("each player needs at least one spawn zone")
+	}
+
+	a := m.Algae
+	if a.Density < 0 || a.PoisonDensity < 0 || a.Density+a.PoisonDensity > 1 {
+		return fmt.Errorf("invalid algae densities %v and %v", a.Density, a.PoisonDensity)
+	}
+
+	switch a.Symmetry {
+	case "", SymmetryNone, SymmetryMirror, SymmetryPoint:
+	default:
+		return fmt.Errorf("invalid algae symmetry %q", a.Symmetry)
+	}
+
+	algae := make(map[Point]bool)
+	for _, t := range a.Fixed {
+		if !m.inBounds(t.Location) {
+			return fmt.Errorf("algae at (%d %d) is out of bounds", t.Location.X, t.Location.Y)
+		}
+		if what, ok := taken[t.Location]; ok {
+			return fmt.Errorf("algae at (%d %d) overlaps %s", t.Location.X, t.Location.Y, what)
+		}
+		if algae[t.Location] {
+			return fmt.Errorf("duplicate algae at (%d %d)", t.Location.X, t.Location.Y)
+		}
+		algae[t.Location] = true
+	}
+
+	return nil
+}
+
+// image is the tile p is paired with under the algae symmetry
+func (m *GameMap) image(p Point) Point {
+	switch m.Algae.Symmetry {
+	case SymmetryMirror:
+		return Point{m.Width - 1 - p.X, p.Y}
+	case SymmetryPoint:
+		return Point{m.Width - 1 - p.X, m.Height - 1 - p.Y}
+	}
+	return p
+}
+
+func (m *GameMap) inBounds(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < m.Width && p.Y < m.Height
+}
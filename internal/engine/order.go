@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"cmp"
+	"slices"
+)
+
+// DefaultAbilityOrder is the order abilities resolve in when a ruleset does
+// not set ability_order
+var DefaultAbilityOrder = []string{"HARVEST", "POISON", "DEPOSIT", "LOCKPICK", "SELFDESTRUCT"}
+
+// sortedKeys lets every loop over moves or entities run in the same order
+// for the same input, map iteration order is random
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (engine *GameEngine) sortedBots() []*Bot {
+	bots := make([]*Bot, 0, len(engine.AllBots))
+	for _, id := range sortedKeys(engine.AllBots) {
+		bots = append(bots, engine.AllBots[id])
+	}
+	return bots
+}
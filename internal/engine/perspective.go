@@ -0,0 +1,102 @@
+package engine
+
+// perspective maps between board coordinates and what a player sees. Player
+// one sees the board as is; player two sees it rotated half a turn
+// (x -> width-1-x, y -> height-1-y), so both players start on the left with
+// their spawn column at x=0 and a strategy plays the same from either seat.
+// The transform is its own inverse and is used for views going out and
+// moves coming in alike.
+type perspective struct {
+	width   int
+	height  int
+	rotated bool
+}
+
+func (engine *GameEngine) perspective(playerID int) perspective {
+	return perspective{
+		width:   engine.Width,
+		height:  engine.Height,
+		rotated: playerID == PlayerTwo,
+	}
+}
+
+func (p perspective) point(pt Point) Point {
+	if !p.rotated {
+		return pt
+	}
+	return Point{X: p.width - 1 - pt.X, Y: p.height - 1 - pt.Y}
+}
+
+func (p perspective) direction(d string) string {
+	if !p.rotated {
+		return d
+	}
+	switch d {
+	case "NORTH":
+		return "SOUTH"
+	case "SOUTH":
+		return "NORTH"
+	case "EAST":
+		return "WEST"
+	case "WEST":
+		return "EAST"
+	}
+	return d
+}
+
+// moves turns a player's moves into board coordinates
+func (p perspective) moves(move PlayerMoves) PlayerMoves {
+	if !p.rotated {
+		return move
+	}
+
+	out := PlayerMoves{
+		Tick:    move.Tick,
+		Spawns:  make(map[int]SpawnCmd, len(move.Spawns)),
+		Actions: make(map[int]ActionCmd, len(move.Actions)),
+	}
+	for botID, spawnCmd := range move.Spawns {
+		spawnCmd.Location = p.point(spawnCmd.Location)
+		out.Spawns[botID] = spawnCmd
+	}
+	for botID, actionCmd := range move.Actions {
+		actionCmd.Direction = p.direction(actionCmd.Direction)
+		out.Actions[botID] = actionCmd
+	}
+	return out
+}
+
+// view turns a freshly built view into the player's coordinates
+func (p perspective) view(v PlayerViewDTO) PlayerViewDTO {
+	if !p.rotated {
+		return v
+	}
+
+	for id, bot := range v.Bots {
+		bot.Location = p.point(bot.Location)
+		v.Bots[id] = bot
+	}
+	for i := range v.VisibleEntities.Enemies {
+		v.VisibleEntities.Enemies[i].Location = p.point(v.VisibleEntities.Enemies[i].Location)
+	}
+	for i := range v.VisibleEntities.Algae {
+		v.VisibleEntities.Algae[i].Location = p.point(v.VisibleEntities.Algae[i].Location)
+	}
+	for id, bank := range v.PermanentEntities.Banks {
+		bank.Location = p.point(bank.Location)
+		v.PermanentEntities.Banks[id] = bank
+	}
+	for id, pad := range v.PermanentEntities.EnergyPads {
+		pad.Location = p.point(pad.Location)
+		v.PermanentEntities.EnergyPads[id] = pad
+	}
+
+	// the engine's wall list is shared, never rotate it in place
+	walls := make([]Point, len(v.PermanentEntities.Walls))
+	for i, w := range v.PermanentEntities.Walls {
+		walls[i] = p.point(w)
+	}
+	v.PermanentEntities.Walls = walls
+
+	return v
+}
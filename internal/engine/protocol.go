@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/delta/code-runner/internal/sandbox"
+)
+
+// Wire protocol versions. A bot that only prints HANDSHAKE_MSG speaks V1
+// with newline JSON. Newer bots send a HelloMsg and receive a WelcomeMsg
+// naming the version and encoding used for the rest of the match; views
+// sent to a bot only change shape behind a version bump.
+const (
+	ProtocolV1 = 1
+	ProtocolV2 = 2
+)
+
+// versions and encodings the engine speaks, most preferred first
+var (
+	SupportedProtocols = []int{ProtocolV2, ProtocolV1}
+	SupportedEncodings = []sandbox.Encoding{sandbox.EncodingMsgpack, sandbox.EncodingJSON}
+)
+
+// Sandbox sends this instead of HANDSHAKE_MSG
+type HelloMsg struct {
+	Hello struct {
+		Versions  []int    `json:"versions"`
+		Encodings []string `json:"encodings"` // in the bot's order of preference
+	} `json:"hello"`
+}
+
+// Sent out to sandbox as newline JSON, before switching encoding
+type WelcomeMsg struct {
+	Version  int      `json:"version"`
+	Encoding string   `json:"encoding"`
+	Ruleset  *Ruleset `json:"ruleset,omitempty"` // balance values of the match
+}
+
+type Protocol struct {
+	Version  int
+	Encoding sandbox.Encoding
+}
+
+// playerViewV1 is the view as protocol v1 bots know it. Fields added to
+// PlayerViewDTO since (mode, bot_id_max, last_results) only reach bots that
+// negotiate v2.
+type playerViewV1 struct {
+	Tick              int                  `json:"tick"`
+	Scraps            int                  `json:"scraps"`
+	Algae             int                  `json:"algae"`
+	BotIDSeed         int                  `json:"bot_id_seed"`
+	MaxBots           int                  `json:"max_bots"`
+	Width             int                  `json:"width"`
+	Height            int                  `json:"height"`
+	Bots              map[int]PlayerBotDTO `json:"bots"`
+	VisibleEntities   VisibleEntitiesDTO   `json:"visible_entities"`
+	PermanentEntities PermanentEntitiesDTO `json:"permanent_entities"`
+}
+
+// view shapes v for the bot's protocol version
+func (p Protocol) view(v PlayerViewDTO) any {
+	if p.Version >= ProtocolV2 {
+		return v
+	}
+
+	return playerViewV1{
+		Tick:              v.Tick,
+		Scraps:            v.Scraps,
+		Algae:             v.Algae,
+		BotIDSeed:         v.BotIDSeed,
+		MaxBots:           v.MaxBots,
+		Width:             v.Width,
+		Height:            v.Height,
+		Bots:              v.Bots,
+		VisibleEntities:   v.VisibleEntities,
+		PermanentEntities: v.PermanentEntities,
+	}
+}
+
+// negotiate picks the highest common version and the bot's most preferred
+// encoding that the engine supports
+func negotiate(hello HelloMsg) (Protocol, error) {
+	p := Protocol{}
+
+	for _, v := range SupportedProtocols {
+		if slices.Contains(hello.Hello.Versions, v) {
+			p.Version = v
+			break
+		}
+	}
+	if p.Version == 0 {
+		return p, fmt.Errorf("no common protocol version in %v", hello.Hello.Versions)
+	}
+
+	for _, e := range hello.Hello.Encodings {
+		if slices.Contains(SupportedEncodings, sandbox.Encoding(e)) {
+			p.Encoding = sandbox.Encoding(e)
+			break
+		}
+	}
+	if p.Encoding == "" {
+		return p, fmt.Errorf("no common encoding in %v", hello.Hello.Encodings)
+	}
+
+	return p, nil
+}
+
+func handshakeSandbox(mCtx context.Context, s *sandbox.Sandbox, timeoutMS uint32, rules *Ruleset) (Protocol, error) {
+	ctx, cancel := context.WithTimeout(mCtx, time.Duration(timeoutMS)*time.Millisecond)
+	defer cancel()
+
+	var raw json.RawMessage
+
+	err := s.RecvOutput(ctx, &raw)
+	if err != nil {
+		return Protocol{}, err
+	}
+
+	legacy := ""
+	if json.Unmarshal(raw, &legacy) == nil {
+		if strings.TrimSpace(legacy) != HANDSHAKE_MSG {
+			return Protocol{}, fmt.Errorf("Invalid handshake")
+		}
+		// v1 has no welcome, so these bots never get the ruleset
+		return Protocol{Version: ProtocolV1, Encoding: sandbox.EncodingJSON}, nil
+	}
+
+	var hello HelloMsg
+	if err := json.Unmarshal(raw, &hello); err != nil {
+		return Protocol{}, fmt.Errorf("Invalid handshake: %w", err)
+	}
+
+	p, err := negotiate(hello)
+	if err != nil {
+		return p, err
+	}
+
+	if err := s.Send(WelcomeMsg{Version: p.Version, Encoding: string(p.Encoding), Ruleset: rules}); err != nil {
+		return p, fmt.Errorf("send welcome: %w", err)
+	}
+
+	s.SetEncoding(p.Encoding)
+
+	return p, nil
+}
@@ -0,0 +1,56 @@
+package engine
+
+// ResultCode says why a spawn or action was not applied, ResultOK when it was
+type ResultCode string
+
+const (
+	ResultOK ResultCode = "OK"
+
+	ResultNoBot        ResultCode = "NO_BOT"        // bot does not exist
+	ResultBotExists    ResultCode = "BOT_EXISTS"    // spawn with an ID already in play
+	ResultNotOwner     ResultCode = "NOT_OWNER"     // bot belongs to the other player
+	ResultOutOfBounds  ResultCode = "OUT_OF_BOUNDS" // tile is off the board
+	ResultOccupied     ResultCode = "OCCUPIED"      // another bot is on the tile
+	ResultWall         ResultCode = "WALL"          // tile is a wall
+	ResultNoScraps     ResultCode = "NO_SCRAPS"     // not enough scraps for the abilities
+	ResultNoEnergy     ResultCode = "NO_ENERGY"     // not enough energy for the move and ability
+	ResultNoAbility    ResultCode = "NO_ABILITY"    // bot lacks the ability
+	ResultContested    ResultCode = "CONTESTED"     // both players went for the same tile, ID or bank
+	ResultNoAlgae      ResultCode = "NO_ALGAE"      // harvest or poison on a tile without algae
+	ResultFull         ResultCode = "FULL"          // bot holds the maximum algae
+	ResultNotNearBank  ResultCode = "NOT_NEAR_BANK" // deposit or lockpick out of bank range
+	ResultNotBankOwner ResultCode = "NOT_BANK_OWNER"
+	ResultBankBusy     ResultCode = "BANK_BUSY" // bank already has a deposit running
+	ResultNothingHeld  ResultCode = "NOTHING_HELD"
+
+	ResultBadBotID     ResultCode = "BAD_BOT_ID"     // spawn ID outside the player's range
+	ResultMaxBots      ResultCode = "MAX_BOTS"       // player already has max_bots alive
+	ResultOnBank       ResultCode = "ON_BANK"        // spawn on a bank tile
+	ResultOnPad        ResultCode = "ON_PAD"         // spawn on an energy pad
+	ResultNotSpawnZone ResultCode = "NOT_SPAWN_ZONE" // spawn outside the player's spawn zones
+)
+
+const (
+	ResultSpawn  = "SPAWN"
+	ResultAction = "ACTION"
+)
+
+// ActionResult is the outcome of one spawn or action of a turn. Applied is
+// true when the command took effect, which for an action means the move and
+// energy were spent even if the ability itself failed with Code.
+type ActionResult struct {
+	BotID   int        `json:"bot_id"`
+	Type    string     `json:"type"` // ResultSpawn or ResultAction
+	Applied bool       `json:"applied"`
+	Code    ResultCode `json:"code"`
+}
+
+// record keeps a result for the next view of playerID
+func (engine *GameEngine) record(playerID int, botID int, typ string, applied bool, code ResultCode) {
+	engine.Results[playerID] = append(engine.Results[playerID], ActionResult{
+		BotID:   botID,
+		Type:    typ,
+		Applied: applied,
+		Code:    code,
+	})
+}
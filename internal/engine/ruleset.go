@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const DefaultRulesetName = "default"
+
+// DefaultBotIDs is the bot ID range of a ruleset without bot_ids
+const DefaultBotIDs = 100
+
+// Abilities a bot can be spawned with, each needs a cost in a ruleset
+var Abilities = []string{"HARVEST", "SCOUT", "SELFDESTRUCT", "LOCKPICK", "SPEEDBOOST", "POISON", "SHIELD"}
+
+// Ruleset holds the balance values of a match. It is read once when the game
+// starts and written to the game log, so editing a ruleset file never changes
+// the rules of a running match. A queued match is only safe from edits when
+// its job pins the ruleset itself, see manager.MatchJob.Rules.
+//
+// A turn resolves in fixed phases so the same moves always give the same
+// state: spawns, then moves, then one phase per ability in AbilityOrder.
+// Within a phase commands run in ascending bot ID order, and in
+// simultaneous mode both players' bots share that order.
+type Ruleset struct {
+	Name string `json:"name"`
+
+	TotalTicks     int `json:"total_ticks"`
+	StartingScraps int `json:"starting_scraps"`
+	MaxBots        int `json:"max_bots"`
+	// size of each player's bot ID range, DefaultBotIDs when 0. Player one
+	// spawns with IDs BotIDs..2*BotIDs-1 and player two with the next BotIDs.
+	BotIDs int `json:"bot_ids"`
+
+	SpawnEnergy       float64 `json:"spawn_energy"`
+	VisionRadius      int     `json:"vision_radius"`
+	ScoutRadius       int     `json:"scout_radius"`
+	BaseMovementCost  float64 `json:"base_movement_cost"`
+	SelfDestructRange int     `json:"self_destruct_range"`
+	BasePadCoolDown   int     `json:"base_pad_cooldown"`
+	BankDepositTime   int     `json:"bank_deposit_time"`
+	BankDepositRange  int     `json:"bank_deposit_range"`
+	LockPickTime      int     `json:"lockpick_time"`
+	MaxAlgaeHeld      int     `json:"max_algae_held"`
+
+	// when false every enemy bot and algae tile is sent to both players
+	FogOfWar bool `json:"fog_of_war"`
+	// with FogOfWar, walls hide the tiles behind them
+	WallsBlockVision bool `json:"walls_block_vision"`
+
+	// order the ability phases resolve in, DefaultAbilityOrder when empty
+	AbilityOrder []string `json:"ability_order"`
+
+	// scraps to spawn a bot with the ability
+	Costs map[string]int `json:"costs"`
+	// energy per move while holding the ability, and per use of it
+	Energy map[string]EnergyCost `json:"energy"`
+}
+
+// LoadRuleset reads the ruleset called name from dir, falling back to the built-in rulesets
+func LoadRuleset(dir string, name string) (*Ruleset, error) {
+	if name == "" {
+		name = DefaultRulesetName
+	}
+
+	b, err := readNamed(dir, "rulesets", "ruleset", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseRuleset(b)
+}
+
+func ParseRuleset(b []byte) (*Ruleset, error) {
+	var r Ruleset
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("parse ruleset: %w", err)
+	}
+
+	if len(r.AbilityOrder) == 0 {
+		r.AbilityOrder = slices.Clone(DefaultAbilityOrder)
+	}
+	if r.BotIDs == 0 {
+		r.BotIDs = DefaultBotIDs
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, fmt.Errorf("ruleset %q: %w", r.Name, err)
+	}
+
+	return &r, nil
+}
+
+// DefaultRuleset is the balance matches used before rulesets existed
+func DefaultRuleset() *Ruleset {
+	r, err := LoadRuleset("", DefaultRulesetName)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Ruleset) Validate() error {
+	if r.Name == "" {
+		return errors.New("missing name")
+	}
+
+	if r.TotalTicks <= 0 {
+		return errors.New("total_ticks must be positive")
+	}
+	if r.MaxBots <= 0 {
+		return errors.New("max_bots must be positive")
+	}
+	if r.BotIDs < r.MaxBots {
+		return fmt.Errorf("max_bots %d needs at least as many bot_ids, got %d", r.MaxBots, r.BotIDs)
+	}
+
+	for name, v := range map[string]float64{
+		"starting_scraps":     float64(r.StartingScraps),
+		"spawn_energy":        r.SpawnEnergy,
+		"vision_radius":       float64(r.VisionRadius),
+		"scout_radius":        float64(r.ScoutRadius),
+		"base_movement_cost":  r.BaseMovementCost,
+		"self_destruct_range": float64(r.SelfDestructRange),
+		"base_pad_cooldown":   float64(r.BasePadCoolDown),
+		"bank_deposit_time":   float64(r.BankDepositTime),
+		"bank_deposit_range":  float64(r.BankDepositRange),
+		"lockpick_time":       float64(r.LockPickTime),
+		"max_algae_held":      float64(r.MaxAlgaeHeld),
+	} {
+		if v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	for _, ability := range Abilities {
+		if _, ok := r.Costs[ability]; !ok {
+			return fmt.Errorf("missing cost for %s", ability)
+		}
+	}
+
+	for ability, cost := range r.Costs {
+		if cost < 0 {
+			return fmt.Errorf("cost of %s must not be negative", ability)
+		}
+	}
+
+	if len(r.AbilityOrder) != len(DefaultAbilityOrder) {
+		return fmt.Errorf("ability_order must list each of %v once", DefaultAbilityOrder)
+	}
+	for _, ability := range DefaultAbilityOrder {
+		if !slices.Contains(r.AbilityOrder, ability) {
+			return fmt.Errorf("ability_order must list each of %v once", DefaultAbilityOrder)
+		}
+	}
+
+	for ability, e := range r.Energy {
+		if e.Traversal < 0 || e.Ability < 0 {
+			return fmt.Errorf("energy of %s must not be negative", ability)
+		}
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/delta/code-runner/internal/config"
@@ -18,9 +20,108 @@ type Match struct {
 	Player2    string
 	Player1Dir string
 	Player2Dir string
+	Mode       string        // ModeAlternate or ModeSimultaneous
+	Map        *GameMap      // DefaultMap when nil
+	Rules      *Ruleset      // DefaultRuleset when nil
+	Seed       int64         // board seed, random when 0 and set once the game started
+	Pool       *sandbox.Pool // optional, cold starts jails when nil or empty
+	Spectators Spectator     // optional, gets every view, move and event live
+	NewSandbox SandboxFunc   // optional, nsjail with the runner config when nil
+	Result     *MatchResult  // set when the game ended
 	gl         *GameLogger
 }
 
+// SandboxFunc creates the sandbox of a submission directory, Match starts it
+type SandboxFunc func(ctx context.Context, dir string) (*sandbox.Sandbox, error)
+
+// MatchResult is the final score of a finished game
+type MatchResult struct {
+	Winner int    `json:"winner"` // PlayerOne, PlayerTwo or Draw
+	Ticks  int    `json:"ticks"`
+	Scraps [2]int `json:"scraps"`
+	Algae  [2]int `json:"algae"` // deposited
+}
+
+// PlayerError is a match failure caused by one player's sandbox
+type PlayerError struct {
+	Player int    // PlayerOne or PlayerTwo
+	Stage  string // sandbox, handshake or turn
+	Err    error
+}
+
+func (e *PlayerError) Error() string {
+	return fmt.Sprintf("p%d %s: %v", e.Player+1, e.Stage, e.Err)
+}
+
+func (e *PlayerError) Unwrap() error {
+	return e.Err
+}
+
+// ErrWallTimeout ends a match that ran out of JailWallTimeoutMS, which is
+// not the fault of whichever player was to move
+var ErrWallTimeout = errors.New("match wall timeout")
+
+// blame makes err a PlayerError of playerID, unless the match as a whole
+// ran out of time
+func blame(matchCtx context.Context, playerID int, stage string, err error) error {
+	if errors.Is(matchCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: p%d %s: %w", ErrWallTimeout, playerID+1, stage, err)
+	}
+	return &PlayerError{playerID, stage, err}
+}
+
+// MatchRecord is what is kept of a played match: who played, how it ended or
+// which side's sandbox failed
+type MatchRecord struct {
+	ID       string       `json:"id"`
+	P1       string       `json:"p1"`
+	P2       string       `json:"p2"`
+	Result   *MatchResult `json:"result,omitempty"`
+	Failed   int          `json:"failed"`          // player whose sandbox failed, -1 when none did
+	Stage    string       `json:"stage,omitempty"` // PlayerError.Stage of the failure
+	Error    string       `json:"error,omitempty"`
+	Seed     int64        `json:"seed,omitempty"`
+	Finished time.Time    `json:"finished"`
+}
+
+// Record describes the match after Simulate returned err
+func (m *Match) Record(err error) MatchRecord {
+	rec := MatchRecord{
+		ID:       m.ID,
+		P1:       m.Player1,
+		P2:       m.Player2,
+		Result:   m.Result,
+		Failed:   -1,
+		Seed:     m.Seed,
+		Finished: time.Now(),
+	}
+
+	if err != nil {
+		rec.Error = err.Error()
+		var playerErr *PlayerError
+		if errors.As(err, &playerErr) {
+			rec.Failed = playerErr.Player
+			rec.Stage = playerErr.Stage
+		}
+	}
+
+	return rec
+}
+
+// Forfeited reports whether rec.Failed lost the match by their own bot, in the
+// handshake or a turn. A sandbox that did not start is the runner's failure,
+// and older records have no stage to tell.
+func (rec MatchRecord) Forfeited() bool {
+	return rec.Failed != -1 && (rec.Stage == "handshake" || rec.Stage == "turn")
+}
+
+// Spectator receives a running match as it is played: a full view at the
+// start of every tick and after the last one, the moves and events of the
+// tick, and the end of the game. Publish must not block.
+type Spectator interface {
+	Publish(typ GameLogType, msg any)
+}
+
 func NewMatch(id, p1, p2, p1Dir, p2Dir string, gl *GameLogger) *Match {
 	return &Match{
 		ID:         id,
@@ -28,56 +129,90 @@ func NewMatch(id, p1, p2, p1Dir, p2Dir string, gl *GameLogger) *Match {
 		Player2:    p2,
 		Player1Dir: p1Dir,
 		Player2Dir: p2Dir,
+		Mode:       ModeAlternate,
 		gl:         gl,
 	}
 }
 
 // returned error is also logged to gameLog file by the manager
 func (m *Match) Simulate(cfg *config.Config) error {
+	if m.Mode != ModeAlternate && m.Mode != ModeSimultaneous {
+		return fmt.Errorf("unknown mode %q", m.Mode)
+	}
+
+	rules := m.Rules
+	if rules == nil {
+		rules = DefaultRuleset()
+	}
+
 	m.gl.Log(GameLogDebug, "Starting sandbox")
 
 	matchCtx, cancelCtx := context.WithTimeout(context.Background(), time.Duration(cfg.JailWallTimeoutMS)*time.Millisecond)
 	defer cancelCtx()
 
-	s1, err := sandbox.NewSandbox(matchCtx, cfg.NsjailPath, cfg.NsjailCfgPath, m.Player1Dir, cfg.JailSubmissionPath)
+	s1, err := m.openSandbox(matchCtx, cfg, m.Player1Dir)
 	if err != nil {
-		return fmt.Errorf("create p1 sandbox: %w", err)
+		return &PlayerError{PlayerOne, "sandbox", err}
 	}
 	defer s1.Destroy()
 
-	s2, err := sandbox.NewSandbox(matchCtx, cfg.NsjailPath, cfg.NsjailCfgPath, m.Player2Dir, cfg.JailSubmissionPath)
+	s2, err := m.openSandbox(matchCtx, cfg, m.Player2Dir)
 	if err != nil {
-		return fmt.Errorf("create p2 sandbox: %w", err)
+		return &PlayerError{PlayerTwo, "sandbox", err}
 	}
 	defer s2.Destroy()
 
 	go streamErrors(matchCtx, s1, m.gl, "p1")
 	go streamErrors(matchCtx, s2, m.gl, "p2")
 
-	if err := s1.Start(); err != nil {
-		return fmt.Errorf("start p1 sandbox: %w", err)
+	p1Proto, err := handshakeSandbox(matchCtx, s1, cfg.JailHandshakeTimeoutMS, rules)
+	if err != nil {
+		return blame(matchCtx, PlayerOne, "handshake", err)
+	}
+
+	p2Proto, err := handshakeSandbox(matchCtx, s2, cfg.JailHandshakeTimeoutMS, rules)
+	if err != nil {
+		return blame(matchCtx, PlayerTwo, "handshake", err)
 	}
-	if err := s2.Start(); err != nil {
-		return fmt.Errorf("start p2 sandbox: %w", err)
+
+	m.gl.Log(GameLogDebug, "Completed Handshakes", fmt.Sprintf("p1=v%d/%s p2=v%d/%s", p1Proto.Version, p1Proto.Encoding, p2Proto.Version, p2Proto.Encoding))
+
+	gameMap := m.Map
+	if gameMap == nil {
+		gameMap = DefaultMap()
 	}
 
-	if err := handshakeSandbox(matchCtx, s1, cfg.JailHandshakeTimeoutMS); err != nil {
-		return fmt.Errorf("p1 handshake: %w", err)
+	var ge *GameEngine
+	if m.Seed != 0 {
+		ge = NewSeededGameEngine(m.gl, gameMap, rules, m.Seed)
+	} else {
+		ge = NewGameEngine(m.gl, gameMap, rules)
+		m.Seed = ge.Seed
 	}
+	ge.Mode = m.Mode
 
-	if err := handshakeSandbox(matchCtx, s2, cfg.JailHandshakeTimeoutMS); err != nil {
-		return fmt.Errorf("p2 handshake: %w", err)
+	if m.Spectators != nil {
+		unsubscribe := ge.Subscribe(func(ev GameEvent) {
+			m.Spectators.Publish(GameLogEvent, ev)
+		})
+		defer unsubscribe()
 	}
 
-	m.gl.Log(GameLogDebug, "Completed Handshakes")
+	protos := [2]Protocol{p1Proto, p2Proto}
 
-	var (
-		ge = InitGameEngine(m.gl)
-		isP1Turn  = true
-	)
+	if m.Mode == ModeSimultaneous {
+		return m.playSimultaneous(matchCtx, cfg, ge, [2]*sandbox.Sandbox{s1, s2}, protos)
+	}
+
+	return m.playAlternate(matchCtx, cfg, ge, s1, s2, protos)
+}
+
+func (m *Match) playAlternate(matchCtx context.Context, cfg *config.Config, ge *GameEngine, s1, s2 *sandbox.Sandbox, protos [2]Protocol) error {
+	isP1Turn := true
+	views := NewViewEncoder(cfg.LogKeyframeInterval)
 
 	for {
-		m.gl.Log(GameLogGameView, ge.getGameView())
+		m.logView(views, ge)
 
 		turnCtx, cancelTurnCtx := context.WithTimeout(matchCtx, time.Duration(cfg.JailTickTimeoutMS)*time.Millisecond)
 
@@ -85,10 +220,10 @@ func (m *Match) Simulate(cfg *config.Config) error {
 		var turnErr error
 
 		if isP1Turn {
-			turnErr = doTurn(turnCtx, s1, m.gl, "p1", ge.GetPlayerView(PlayerOne), &move)
+			turnErr = doTurn(turnCtx, s1, m.gl, "p1", protos[PlayerOne].view(ge.GetPlayerView(PlayerOne)), &move)
 			m.gl.Log(GameLogDebug, "Completed Turn")
 		} else {
-			turnErr = doTurn(turnCtx, s2, m.gl, "p2", ge.GetPlayerView(PlayerTwo), &move)
+			turnErr = doTurn(turnCtx, s2, m.gl, "p2", protos[PlayerTwo].view(ge.GetPlayerView(PlayerTwo)), &move)
 			m.gl.Log(GameLogDebug, "Completed Turn")
 		}
 
@@ -99,20 +234,24 @@ func (m *Match) Simulate(cfg *config.Config) error {
 			// or skip turn and wait till N consecutive turn errors to end the match?
 			// TODO: declare other player as winner
 			if isP1Turn {
-				return fmt.Errorf("p1 turn: %w", turnErr)
+				return blame(matchCtx, PlayerOne, "turn", turnErr)
 			} else {
-				return fmt.Errorf("p2 turn: %w", turnErr)
+				return blame(matchCtx, PlayerTwo, "turn", turnErr)
 			}
 		}
 
-		m.gl.Log(GameLogGameMove, move)
+		if isP1Turn {
+			m.logMove(ge, PlayerOne, move)
+		} else {
+			m.logMove(ge, PlayerTwo, move)
+		}
 
 		ge.UpdateState(move)
 
 		isP1Turn = !isP1Turn
 
 		if ge.Winner != -1 {
-			m.gl.Log(GameLogDebug, fmt.Sprintf("Game Successfully Ended with status %d", ge.Winner))
+			m.logEnd(ge)
 			break
 		}
 	}
@@ -120,25 +259,132 @@ func (m *Match) Simulate(cfg *config.Config) error {
 	return nil
 }
 
-func handshakeSandbox(mCtx context.Context, s *sandbox.Sandbox, timeoutMS uint32) error {
-	ctx, cancel := context.WithTimeout(mCtx, time.Duration(timeoutMS) * time.Millisecond)
-	defer cancel()
+// both sandboxes get the view of the same tick at once and share the tick timeout
+func (m *Match) playSimultaneous(matchCtx context.Context, cfg *config.Config, ge *GameEngine, sandboxes [2]*sandbox.Sandbox, protos [2]Protocol) error {
+	labels := [2]string{"p1", "p2"}
+	views := NewViewEncoder(cfg.LogKeyframeInterval)
 
-	data := ""
+	for {
+		m.logView(views, ge)
+
+		turnCtx, cancelTurnCtx := context.WithTimeout(matchCtx, time.Duration(cfg.JailTickTimeoutMS)*time.Millisecond)
+
+		var (
+			moves    [2]PlayerMoves
+			turnErrs [2]error
+			wg       sync.WaitGroup
+		)
+
+		for playerID := range sandboxes {
+			view := protos[playerID].view(ge.GetPlayerView(playerID))
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				turnErrs[playerID] = doTurn(turnCtx, sandboxes[playerID], m.gl, labels[playerID], view, &moves[playerID])
+			}()
+		}
+		wg.Wait()
+
+		cancelTurnCtx()
+		m.gl.Log(GameLogDebug, "Completed Turn")
+
+		for playerID, turnErr := range turnErrs {
+			if turnErr != nil {
+				return blame(matchCtx, playerID, "turn", turnErr)
+			}
+		}
+
+		m.logMove(ge, PlayerOne, moves[PlayerOne])
+		m.logMove(ge, PlayerTwo, moves[PlayerTwo])
+
+		ge.UpdateStateSimultaneous(moves)
+
+		if ge.Winner != -1 {
+			m.logEnd(ge)
+			break
+		}
+	}
+
+	return nil
+}
 
-	err := s.RecvOutput(ctx, &data)
+func (m *Match) logView(views *ViewEncoder, ge *GameEngine) {
+	view := ge.GameView()
+
+	typ, msg, err := views.Encode(view)
 	if err != nil {
-		return err
+		m.gl.Log(GameLogWarn, "encode view:", err.Error())
+		typ, msg = GameLogGameView, view
 	}
+	m.gl.Log(typ, msg)
 
-	if strings.TrimSpace(data) != HANDSHAKE_MSG {
-		return fmt.Errorf("Invalid handshake")
+	// spectators may join at any tick, so they always get the full view
+	if m.Spectators != nil {
+		m.Spectators.Publish(GameLogGameView, view)
 	}
+}
 
-	return nil
+// moves are logged in board coordinates like the views they are drawn on
+func (m *Match) logMove(ge *GameEngine, playerID int, move PlayerMoves) {
+	move = ge.perspective(playerID).moves(move)
+	m.gl.Log(GameLogGameMove, move)
+	if m.Spectators != nil {
+		m.Spectators.Publish(GameLogGameMove, move)
+	}
+}
+
+// the log ends on the last move, spectators also see the final board
+func (m *Match) logEnd(ge *GameEngine) {
+	m.Result = &MatchResult{
+		Winner: ge.Winner,
+		Ticks:  ge.Ticks,
+		Scraps: ge.Scraps,
+		Algae:  ge.PermanentAlgae,
+	}
+
+	msg := fmt.Sprintf("Game Successfully Ended with status %d", ge.Winner)
+	m.gl.Log(GameLogDebug, msg)
+	if m.Spectators != nil {
+		m.Spectators.Publish(GameLogGameView, ge.GameView())
+		m.Spectators.Publish(GameLogDebug, msg)
+	}
+}
+
+// openSandbox returns a started sandbox for the submission in dir, taking a
+// warm one from the pool when possible
+func (m *Match) openSandbox(ctx context.Context, cfg *config.Config, dir string) (*sandbox.Sandbox, error) {
+	if m.Pool != nil {
+		s, err := m.Pool.Acquire(ctx, dir)
+		if err == nil {
+			m.gl.Log(GameLogDebug, "Using warm sandbox")
+			return s, nil
+		}
+		if !errors.Is(err, sandbox.ErrPoolEmpty) {
+			m.gl.Log(GameLogWarn, "warm sandbox:", err.Error())
+		}
+	}
+
+	var s *sandbox.Sandbox
+	var err error
+	if m.NewSandbox != nil {
+		s, err = m.NewSandbox(ctx, dir)
+	} else {
+		s, err = sandbox.NewSandbox(ctx, cfg.NsjailPath, cfg.NsjailCfgPath, dir, cfg.JailSubmissionPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create: %w", err)
+	}
+
+	if err := s.Start(); err != nil {
+		s.Destroy()
+		return nil, fmt.Errorf("start: %w", err)
+	}
+
+	return s, nil
 }
 
-func doTurn(turnCtx context.Context, s *sandbox.Sandbox, gl *GameLogger, label string, playerView PlayerViewDTO, out *PlayerMoves) error {
+func doTurn(turnCtx context.Context, s *sandbox.Sandbox, gl *GameLogger, label string, playerView any, out *PlayerMoves) error {
 	gl.Log(GameLogDebug, label, "Sending state")
 
 	if err := s.Send(playerView); err != nil {
@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+type plannedAction struct {
+	playerID int
+	botID    int
+	cmd      ActionCmd
+	cost     float64
+	moving   bool
+	dest     Point
+	rejected bool
+	code     ResultCode // why it was rejected, or how its ability went
+}
+
+// UpdateStateSimultaneous applies the moves both players chose for the same
+// tick (simultaneous mode). Everything is validated against the board at the
+// start of the tick, then resolved in phases with these conflict rules:
+//
+//   - spawns of both players on the same tile or with the same bot ID are all rejected
+//   - bots of both players moving onto the same tile have their whole actions
+//     rejected, which also covers a race for the same energy pad
+//   - a move onto a tile that is occupied at the start of the tick is
+//     rejected as OCCUPIED, even when that bot moves away in the same tick, so
+//     bots cannot follow each other in a line or swap places
+//   - moves happen in bot ID order and before any ability, so abilities see
+//     the moved board; of two own bots moving onto the same free tile the
+//     lower ID moves and the other is rejected as OCCUPIED
+//   - abilities resolve in the ruleset's ability_order, by default harvests and
+//     poisons before deposits, deposits before lockpicks: a deposit started in
+//     the same tick as a lockpick on that bank is exposed to it
+//   - lockpicks by both players on the same bank are all rejected
+//   - self-destructs explode together: every blast is computed before any bot
+//     is removed and a shield absorbs one blast
+func (engine *GameEngine) UpdateStateSimultaneous(moves [2]PlayerMoves) {
+	for playerID := range moves {
+		moves[playerID] = engine.perspective(playerID).moves(moves[playerID])
+		engine.Results[playerID] = nil
+	}
+
+	engine.resolveSimultaneousSpawns(moves)
+
+	planned := engine.planActions(moves)
+
+	for _, a := range planned {
+		if a.rejected {
+			continue
+		}
+		// an own bot may have taken the tile earlier in this phase
+		if a.moving && engine.LocationOccupied(a.dest) {
+			engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d attempted to move at Occupied Location at (%d %d)", a.botID, a.dest.X, a.dest.Y))
+			a.rejected = true
+			a.code = ResultOccupied
+			continue
+		}
+
+		engine.getBot(a.botID).Energy -= a.cost
+		if a.moving {
+			engine.moveBot(a.botID, a.cmd.Direction)
+		}
+	}
+
+	for _, phase := range engine.Rules.AbilityOrder {
+		switch phase {
+		case "LOCKPICK":
+			engine.resolveSimultaneousLockPicks(planned)
+		case "SELFDESTRUCT":
+			engine.resolveSimultaneousSelfDestructs(planned)
+		default:
+			for _, a := range planned {
+				if !a.rejected && a.cmd.Action == phase {
+					a.code = engine.abilityIfAlive(a)
+				}
+			}
+		}
+	}
+
+	for _, a := range planned {
+		engine.record(a.playerID, a.botID, ResultAction, !a.rejected, a.code)
+	}
+
+	engine.TickPermanentEntities()
+	engine.CheckWinCondition()
+	engine.Ticks++
+}
+
+func (engine *GameEngine) resolveSimultaneousSpawns(moves [2]PlayerMoves) {
+	contested := func(playerID int, botID int, spawn SpawnCmd) bool {
+		for otherID, other := range moves[1-playerID].Spawns {
+			if otherID == botID || other.Location == spawn.Location {
+				return true
+			}
+		}
+		return false
+	}
+
+	for playerID, move := range moves {
+		for _, botID := range sortedKeys(move.Spawns) {
+			spawnCmd := move.Spawns[botID]
+			if contested(playerID, botID, spawnCmd) {
+				engine.gl.Log(GameLogWarn, fmt.Sprintf("BotID=%d spawn contested by the other player", botID))
+				engine.record(playerID, botID, ResultSpawn, false, ResultContested)
+				continue
+			}
+			engine.spawnBot(spawnCmd, playerID, botID)
+		}
+	}
+}
+
+func (engine *GameEngine) planActions(moves [2]PlayerMoves) []*plannedAction {
+	planned := make([]*plannedAction, 0)
+	byDest := make(map[Point][]*plannedAction)
+
+	for playerID, move := range moves {
+		for _, botID := range sortedKeys(move.Actions) {
+			actionCmd := move.Actions[botID]
+			code, energyCost := engine.validateMove(playerID, botID, actionCmd)
+			if code != ResultOK {
+				engine.gl.Log(GameLogWarn, fmt.Sprintf("Cannot to perform action for BotID=%d", botID))
+				engine.record(playerID, botID, ResultAction, false, code)
+				continue
+			}
+
+			bot := engine.getBot(botID)
+			a := &plannedAction{
+				playerID: playerID,
+				botID:    botID,
+				cmd:      actionCmd,
+				cost:     energyCost,
+				code:     ResultOK,
+			}
+			if !isStay(actionCmd.Direction) {
+				a.moving = true
+				a.dest, _ = engine.destination(bot, actionCmd.Direction)
+				byDest[a.dest] = append(byDest[a.dest], a)
+			}
+			planned = append(planned, a)
+		}
+	}
+
+	for _, a := range planned {
+		if !a.moving || !spansBothPlayers(byDest[a.dest]) {
+			continue
+		}
+		engine.gl.Log(GameLogWarn, fmt.Sprintf("botID=%d move to (%d %d) contested by the other player", a.botID, a.dest.X, a.dest.Y))
+		a.rejected = true
+		a.code = ResultContested
+	}
+
+	// both players' bots share one order
+	slices.SortStableFunc(planned, func(a, b *plannedAction) int {
+		return cmp.Compare(a.botID, b.botID)
+	})
+
+	return planned
+}
+
+func (engine *GameEngine) resolveSimultaneousLockPicks(planned []*plannedAction) {
+	byBank := make(map[int][]*plannedAction)
+
+	for _, a := range planned {
+		if a.rejected || a.cmd.Action != "LOCKPICK" {
+			continue
+		}
+		if engine.getBot(a.botID) == nil {
+			a.code = ResultNoBot
+			continue
+		}
+		if nearBank, bankID := engine.isNearBank(a.botID); nearBank {
+			byBank[bankID] = append(byBank[bankID], a)
+		} else {
+			a.code = engine.startLockPick(a.botID) // logs the failure
+		}
+	}
+
+	for _, bankID := range sortedKeys(byBank) {
+		as := byBank[bankID]
+		if spansBothPlayers(as) {
+			engine.gl.Log(GameLogWarn, fmt.Sprintf("LockPick at bankID=%d contested by both players", bankID))
+			for _, a := range as {
+				a.code = ResultContested
+			}
+			continue
+		}
+		for _, a := range as {
+			a.code = engine.startLockPick(a.botID)
+		}
+	}
+}
+
+func (engine *GameEngine) resolveSimultaneousSelfDestructs(planned []*plannedAction) {
+	exploding := make([]*Bot, 0)
+	for _, a := range planned {
+		if a.rejected || a.cmd.Action != "SELFDESTRUCT" {
+			continue
+		}
+		if bot := engine.getBot(a.botID); bot != nil {
+			exploding = append(exploding, bot)
+		} else {
+			a.code = ResultNoBot
+		}
+	}
+
+	hits := make(map[int]int)
+	blastedBy := make(map[int]int)
+	for _, bot := range exploding {
+		for _, botB := range engine.sortedBots() {
+			if absDiffInt(bot.Location.X, botB.Location.X) <= engine.Rules.SelfDestructRange && absDiffInt(bot.Location.Y, botB.Location.Y) <= engine.Rules.SelfDestructRange {
+				hits[botB.ID]++
+				blastedBy[botB.ID] = bot.ID
+			}
+		}
+	}
+
+	for _, bot := range exploding {
+		engine.killBot(bot.ID, KillCauseSelfDestruct, -1)
+		delete(hits, bot.ID)
+	}
+
+	for _, botID := range sortedKeys(hits) {
+		n := hits[botID]
+		if engine.hasAbility(botID, "SHIELD") {
+			engine.removeShield(botID)
+			n--
+		}
+		if n > 0 {
+			engine.killBot(botID, KillCauseBlast, blastedBy[botID])
+		}
+	}
+}
+
+func spansBothPlayers(as []*plannedAction) bool {
+	for _, a := range as {
+		if a.playerID != as[0].playerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package engine
+
+import (
+	"io"
+	"testing"
+)
+
+// newTestEngine returns an engine on the default board without bots
+func newTestEngine(t *testing.T) *GameEngine {
+	t.Helper()
+
+	ge := NewSeededGameEngine(NewGameLogger(io.Discard), DefaultMap(), DefaultRuleset(), 1)
+	ge.Mode = ModeSimultaneous
+	return ge
+}
+
+// putBot places a bot as if it had spawned there
+func putBot(ge *GameEngine, botID, playerID int, loc Point, abilities ...string) *Bot {
+	bot := &Bot{
+		ID:            botID,
+		OwnerID:       playerID,
+		Location:      loc,
+		Energy:        ge.Rules.SpawnEnergy,
+		Abilities:     abilities,
+		VisionRadius:  ge.Rules.VisionRadius,
+		TraversalCost: ge.calculateTraversalCost(abilities),
+	}
+	ge.AllBots[botID] = bot
+	return bot
+}
+
+// resultOf returns the result of botID's spawn or action for playerID
+func resultOf(t *testing.T, ge *GameEngine, playerID, botID int, typ string) ActionResult {
+	t.Helper()
+
+	for _, r := range ge.Results[playerID] {
+		if r.BotID == botID && r.Type == typ {
+			return r
+		}
+	}
+	t.Fatalf("no %s result for bot %d of player %d", typ, botID, playerID)
+	return ActionResult{}
+}
+
+type placedBot struct {
+	id, player int
+	loc        Point
+	abilities  []string
+}
+
+type wantResult struct {
+	player, botID int
+	typ           string
+	applied       bool
+	code          ResultCode
+}
+
+func TestUpdateStateSimultaneous(t *testing.T) {
+	tests := []struct {
+		name  string
+		bots  []placedBot
+		moves [2]PlayerMoves // in board coordinates
+		want  []wantResult
+		check func(t *testing.T, ge *GameEngine)
+	}{
+		{
+			name: "spawns on the same tile",
+			moves: [2]PlayerMoves{
+				{Spawns: map[int]SpawnCmd{100: {Location: Point{X: 0, Y: 5}}, 101: {Location: Point{X: 0, Y: 7}}}},
+				{Spawns: map[int]SpawnCmd{200: {Location: Point{X: 0, Y: 5}}, 201: {Location: Point{X: 19, Y: 7}}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 100, ResultSpawn, false, ResultContested},
+				{PlayerTwo, 200, ResultSpawn, false, ResultContested},
+				{PlayerOne, 101, ResultSpawn, true, ResultOK},
+				{PlayerTwo, 201, ResultSpawn, true, ResultOK},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				if ge.getBot(100) != nil || ge.getBot(200) != nil {
+					t.Error("a contested spawn was placed")
+				}
+			},
+		},
+		{
+			name: "spawns with the same bot ID",
+			moves: [2]PlayerMoves{
+				{Spawns: map[int]SpawnCmd{150: {Location: Point{X: 0, Y: 5}}}},
+				{Spawns: map[int]SpawnCmd{150: {Location: Point{X: 19, Y: 5}}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 150, ResultSpawn, false, ResultContested},
+				{PlayerTwo, 150, ResultSpawn, false, ResultContested},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				if ge.getBot(150) != nil {
+					t.Error("a contested spawn was placed")
+				}
+			},
+		},
+		{
+			name: "moves onto the same tile",
+			bots: []placedBot{
+				{100, PlayerOne, Point{X: 9, Y: 2}, []string{"HARVEST"}},
+				{200, PlayerTwo, Point{X: 11, Y: 2}, nil},
+			},
+			moves: [2]PlayerMoves{
+				{Actions: map[int]ActionCmd{100: {Action: "HARVEST", Direction: "EAST"}}},
+				{Actions: map[int]ActionCmd{200: {Action: "MOVE", Direction: "WEST"}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 100, ResultAction, false, ResultContested},
+				{PlayerTwo, 200, ResultAction, false, ResultContested},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				// the whole action is dropped, energy included
+				for id, loc := range map[int]Point{100: {X: 9, Y: 2}, 200: {X: 11, Y: 2}} {
+					bot := ge.getBot(id)
+					if bot.Location != loc || bot.Energy != ge.Rules.SpawnEnergy {
+						t.Errorf("bot %d at %v with %.1f energy, want %v with %.1f", id, bot.Location, bot.Energy, loc, ge.Rules.SpawnEnergy)
+					}
+				}
+			},
+		},
+		{
+			name: "own bots onto the same tile go by bot ID",
+			bots: []placedBot{
+				{100, PlayerOne, Point{X: 2, Y: 12}, nil},
+				{101, PlayerOne, Point{X: 4, Y: 12}, nil},
+			},
+			moves: [2]PlayerMoves{
+				{Actions: map[int]ActionCmd{100: {Action: "MOVE", Direction: "EAST"}, 101: {Action: "MOVE", Direction: "WEST"}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 100, ResultAction, true, ResultOK},
+				{PlayerOne, 101, ResultAction, false, ResultOccupied},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				if loc := ge.getBot(100).Location; loc != (Point{X: 3, Y: 12}) {
+					t.Errorf("bot 100 at %v, want (3 12)", loc)
+				}
+				if bot := ge.getBot(101); bot.Location != (Point{X: 4, Y: 12}) || bot.Energy != ge.Rules.SpawnEnergy {
+					t.Errorf("bot 101 at %v with %.1f energy, want it unmoved", bot.Location, bot.Energy)
+				}
+			},
+		},
+		{
+			name: "a bot cannot follow a bot moving away",
+			bots: []placedBot{
+				{100, PlayerOne, Point{X: 2, Y: 10}, nil},
+				{101, PlayerOne, Point{X: 3, Y: 10}, nil},
+			},
+			moves: [2]PlayerMoves{
+				{Actions: map[int]ActionCmd{100: {Action: "MOVE", Direction: "EAST"}, 101: {Action: "MOVE", Direction: "EAST"}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 100, ResultAction, false, ResultOccupied},
+				{PlayerOne, 101, ResultAction, true, ResultOK},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				if loc := ge.getBot(100).Location; loc != (Point{X: 2, Y: 10}) {
+					t.Errorf("bot 100 at %v, want (2 10)", loc)
+				}
+			},
+		},
+		{
+			name: "lockpicks on the same bank",
+			bots: []placedBot{
+				{100, PlayerOne, Point{X: 16, Y: 3}, []string{"LOCKPICK"}},
+				{200, PlayerTwo, Point{X: 14, Y: 2}, []string{"LOCKPICK"}},
+				{101, PlayerOne, Point{X: 5, Y: 16}, []string{"LOCKPICK"}},
+			},
+			moves: [2]PlayerMoves{
+				{Actions: map[int]ActionCmd{100: {Action: "LOCKPICK"}, 101: {Action: "LOCKPICK"}}},
+				{Actions: map[int]ActionCmd{200: {Action: "LOCKPICK"}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 100, ResultAction, true, ResultContested},
+				{PlayerTwo, 200, ResultAction, true, ResultContested},
+				{PlayerOne, 101, ResultAction, true, ResultOK},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				if ge.Banks[2].LockPickOccuring {
+					t.Error("contested lockpick started on bank 2")
+				}
+				if bank := ge.Banks[3]; !bank.LockPickOccuring || bank.LockPickBotID != 101 {
+					t.Errorf("bank 3 lockpick %v by %d, want bot 101", bank.LockPickOccuring, bank.LockPickBotID)
+				}
+			},
+		},
+		{
+			name: "self-destructs explode together",
+			bots: []placedBot{
+				{100, PlayerOne, Point{X: 10, Y: 2}, []string{"SELFDESTRUCT"}},
+				{200, PlayerTwo, Point{X: 11, Y: 2}, []string{"SELFDESTRUCT"}},
+				// in both blasts, the shield only absorbs one
+				{201, PlayerTwo, Point{X: 10, Y: 3}, []string{"SHIELD"}},
+				// in one blast each
+				{101, PlayerOne, Point{X: 9, Y: 1}, []string{"SHIELD"}},
+				{202, PlayerTwo, Point{X: 12, Y: 1}, nil},
+				// out of range
+				{102, PlayerOne, Point{X: 8, Y: 2}, nil},
+			},
+			moves: [2]PlayerMoves{
+				{Actions: map[int]ActionCmd{100: {Action: "SELFDESTRUCT"}}},
+				{Actions: map[int]ActionCmd{200: {Action: "SELFDESTRUCT"}}},
+			},
+			want: []wantResult{
+				{PlayerOne, 100, ResultAction, true, ResultOK},
+				{PlayerTwo, 200, ResultAction, true, ResultOK},
+			},
+			check: func(t *testing.T, ge *GameEngine) {
+				for _, id := range []int{100, 200, 201, 202} {
+					if ge.getBot(id) != nil {
+						t.Errorf("bot %d survived", id)
+					}
+				}
+				for _, id := range []int{101, 102} {
+					if ge.getBot(id) == nil {
+						t.Errorf("bot %d was killed", id)
+					}
+				}
+				if ge.hasAbility(101, "SHIELD") {
+					t.Error("bot 101 kept the shield that absorbed a blast")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ge := newTestEngine(t)
+			for _, b := range tt.bots {
+				putBot(ge, b.id, b.player, b.loc, b.abilities...)
+			}
+
+			// player two sends moves in their own perspective
+			moves := tt.moves
+			moves[PlayerTwo] = ge.perspective(PlayerTwo).moves(moves[PlayerTwo])
+			ge.UpdateStateSimultaneous(moves)
+
+			for _, w := range tt.want {
+				r := resultOf(t, ge, w.player, w.botID, w.typ)
+				if r.Applied != w.applied || r.Code != w.code {
+					t.Errorf("player %d bot %d %s: applied %v %s, want %v %s", w.player, w.botID, w.typ, r.Applied, r.Code, w.applied, w.code)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, ge)
+			}
+		})
+	}
+}
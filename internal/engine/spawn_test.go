@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"io"
+	"testing"
+)
+
+func TestValidateSpawn(t *testing.T) {
+	// player one may also spawn on the wall at (3 6), bank 1 at (4 4) and
+	// pad 1 at (9 8), so those checks are reached
+	m := DefaultMap()
+	m.SpawnZones[0] = SpawnZone{Owner: PlayerOne, Min: Point{X: 0, Y: 0}, Max: Point{X: 9, Y: 19}}
+
+	free := Point{X: 0, Y: 5}
+
+	tests := []struct {
+		name      string
+		player    int
+		botID     int
+		loc       Point
+		abilities []string
+		setup     func(ge *GameEngine)
+		want      ResultCode
+	}{
+		{name: "ok", player: PlayerOne, botID: 100, loc: free, abilities: []string{"HARVEST"}, want: ResultOK},
+		{name: "ok for player two", player: PlayerTwo, botID: 200, loc: Point{X: 19, Y: 5}, want: ResultOK},
+		{name: "below the range", player: PlayerOne, botID: 99, loc: free, want: ResultBadBotID},
+		{name: "last ID of the range", player: PlayerOne, botID: 199, loc: free, want: ResultOK},
+		{name: "in the other player's range", player: PlayerOne, botID: 200, loc: free, want: ResultBadBotID},
+		{name: "player two below the range", player: PlayerTwo, botID: 199, loc: Point{X: 19, Y: 5}, want: ResultBadBotID},
+		{name: "player two above the range", player: PlayerTwo, botID: 300, loc: Point{X: 19, Y: 5}, want: ResultBadBotID},
+		{
+			name: "ID of a live bot", player: PlayerOne, botID: 100, loc: free, want: ResultBotExists,
+			setup: func(ge *GameEngine) { putBot(ge, 100, PlayerOne, Point{X: 0, Y: 9}) },
+		},
+		{
+			name: "ID of a dead bot", player: PlayerOne, botID: 100, loc: free, want: ResultOK,
+			setup: func(ge *GameEngine) {
+				putBot(ge, 100, PlayerOne, Point{X: 0, Y: 9})
+				ge.KillBot(100)
+			},
+		},
+		{
+			name: "max bots alive", player: PlayerOne, botID: 102, loc: free, want: ResultMaxBots,
+			setup: func(ge *GameEngine) {
+				ge.MaxBots = 2
+				putBot(ge, 100, PlayerOne, Point{X: 0, Y: 8})
+				putBot(ge, 101, PlayerOne, Point{X: 0, Y: 9})
+			},
+		},
+		{
+			name: "other player's bots do not count", player: PlayerOne, botID: 100, loc: free, want: ResultOK,
+			setup: func(ge *GameEngine) {
+				ge.MaxBots = 2
+				putBot(ge, 200, PlayerTwo, Point{X: 19, Y: 8})
+				putBot(ge, 201, PlayerTwo, Point{X: 19, Y: 9})
+			},
+		},
+		{name: "left of the board", player: PlayerOne, botID: 100, loc: Point{X: -1, Y: 5}, want: ResultOutOfBounds},
+		{name: "below the board", player: PlayerOne, botID: 100, loc: Point{X: 0, Y: 20}, want: ResultOutOfBounds},
+		{
+			name: "occupied", player: PlayerOne, botID: 100, loc: free, want: ResultOccupied,
+			setup: func(ge *GameEngine) { putBot(ge, 200, PlayerTwo, free) },
+		},
+		{name: "wall", player: PlayerOne, botID: 100, loc: Point{X: 3, Y: 6}, want: ResultWall},
+		{name: "bank", player: PlayerOne, botID: 100, loc: Point{X: 4, Y: 4}, want: ResultOnBank},
+		{name: "energy pad", player: PlayerOne, botID: 100, loc: Point{X: 9, Y: 8}, want: ResultOnPad},
+		{name: "outside the spawn zone", player: PlayerOne, botID: 100, loc: Point{X: 10, Y: 5}, want: ResultNotSpawnZone},
+		{name: "other player's spawn zone", player: PlayerOne, botID: 100, loc: Point{X: 19, Y: 5}, want: ResultNotSpawnZone},
+		{name: "unknown ability", player: PlayerOne, botID: 100, loc: free, abilities: []string{"HARVEST", "TELEPORT"}, want: ResultNoAbility},
+		{
+			name: "not enough scraps", player: PlayerOne, botID: 100, loc: free, abilities: []string{"HARVEST", "SCOUT"}, want: ResultNoScraps,
+			setup: func(ge *GameEngine) { ge.Scraps[PlayerOne] = 19 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ge := NewSeededGameEngine(NewGameLogger(io.Discard), m, DefaultRuleset(), 1)
+			if tt.setup != nil {
+				tt.setup(ge)
+			}
+			scraps := ge.Scraps[tt.player]
+
+			ok := ge.spawnBot(SpawnCmd{Abilities: tt.abilities, Location: tt.loc}, tt.player, tt.botID)
+
+			r := resultOf(t, ge, tt.player, tt.botID, ResultSpawn)
+			if r.Code != tt.want || r.Applied != (tt.want == ResultOK) || ok != r.Applied {
+				t.Fatalf("got applied %v %s, want %s", r.Applied, r.Code, tt.want)
+			}
+
+			bot := ge.getBot(tt.botID)
+			if !ok {
+				if ge.Scraps[tt.player] != scraps {
+					t.Errorf("rejected spawn cost %d scraps", scraps-ge.Scraps[tt.player])
+				}
+				return
+			}
+			if bot == nil || bot.OwnerID != tt.player || bot.Location != tt.loc {
+				t.Fatalf("spawned %+v", bot)
+			}
+			cost := 0
+			for _, a := range tt.abilities {
+				cost += ge.Rules.Costs[a]
+			}
+			if got := scraps - ge.Scraps[tt.player]; got != cost {
+				t.Errorf("spawn cost %d scraps, want %d", got, cost)
+			}
+		})
+	}
+}
+
+// every player gets bot_ids IDs of their own, and the views say which
+func TestBotIDRanges(t *testing.T) {
+	rules := DefaultRuleset()
+	rules.BotIDs = 10
+	rules.MaxBots = 10
+
+	ge := NewSeededGameEngine(NewGameLogger(io.Discard), DefaultMap(), rules, 1)
+
+	want := [2][2]int{{10, 19}, {20, 29}}
+	for playerID, r := range want {
+		view := ge.GetPlayerView(playerID)
+		if view.BotIDSeed != r[0] || view.BotIDMax != r[1] {
+			t.Errorf("player %d range %d..%d, want %d..%d", playerID, view.BotIDSeed, view.BotIDMax, r[0], r[1])
+		}
+
+		zone := Point{X: 0, Y: 5}
+		if playerID == PlayerTwo {
+			zone = Point{X: 19, Y: 5}
+		}
+		for _, id := range []int{r[0] - 1, r[0], r[1], r[1] + 1} {
+			code, _ := ge.validateSpawn(SpawnCmd{Location: zone}, playerID, id)
+			if in := id >= r[0] && id <= r[1]; in != (code == ResultOK) {
+				t.Errorf("player %d bot %d: %s", playerID, id, code)
+			}
+		}
+	}
+}
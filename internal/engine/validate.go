@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/sandbox"
+)
+
+// BuiltinOpponent names the opponent of a validation, it passes every turn
+// so only the submission can go wrong
+const BuiltinOpponent = "builtin-idle"
+
+const (
+	// time a crashed submission gets to finish writing its traceback
+	stderrGrace = 200 * time.Millisecond
+	// stderr kept for a report, the first tracebacks are the useful ones
+	maxStderr = 64 * 1024
+)
+
+// ValidationReport says whether a submission survives a few turns: it must
+// handshake and answer every turn without a traceback. Invalid moves are
+// reported but do not fail it, a bot may try moves the ruleset rejects.
+type ValidationReport struct {
+	ID           string        `json:"id"`
+	Player       string        `json:"player"`
+	Passed       bool          `json:"passed"`
+	Handshake    bool          `json:"handshake"`
+	Protocol     string        `json:"protocol,omitempty"` // v<version>/<encoding> after the handshake
+	FirstTurnMS  float64       `json:"first_turn_ms"`
+	MaxTurnMS    float64       `json:"max_turn_ms"`
+	Turns        int           `json:"turns"` // answered by the submission
+	InvalidMoves []InvalidMove `json:"invalid_moves"`
+	Tracebacks   []string      `json:"tracebacks"`
+	Crash        string        `json:"crash,omitempty"` // why the submission stopped before all turns
+	Seed         int64         `json:"seed,omitempty"`
+	Finished     time.Time     `json:"finished"`
+}
+
+// InvalidMove is a spawn or action of the submission that was rejected, or
+// an action whose ability failed, at the tick of the view it answered
+type InvalidMove struct {
+	Tick int `json:"tick"`
+	ActionResult
+}
+
+// Validate seats the submission of Player1 against BuiltinOpponent for up to
+// turns turns of its own and reports how it went. Player2 is not used. The
+// report is also returned when the submission fails, a nil report means the
+// test itself could not run.
+func (m *Match) Validate(cfg *config.Config, turns int) (*ValidationReport, error) {
+	if m.Mode != ModeAlternate && m.Mode != ModeSimultaneous {
+		return nil, fmt.Errorf("unknown mode %q", m.Mode)
+	}
+
+	rules := m.Rules
+	if rules == nil {
+		rules = DefaultRuleset()
+	}
+
+	gameMap := m.Map
+	if gameMap == nil {
+		gameMap = DefaultMap()
+	}
+
+	var ge *GameEngine
+	if m.Seed != 0 {
+		ge = NewSeededGameEngine(m.gl, gameMap, rules, m.Seed)
+	} else {
+		ge = NewGameEngine(m.gl, gameMap, rules)
+		m.Seed = ge.Seed
+	}
+	ge.Mode = m.Mode
+
+	report := &ValidationReport{
+		ID:           m.ID,
+		Player:       m.Player1,
+		Seed:         m.Seed,
+		InvalidMoves: []InvalidMove{},
+		Tracebacks:   []string{},
+	}
+	defer func() { report.Finished = time.Now() }()
+
+	m.gl.Log(GameLogDebug, "Starting sandbox")
+
+	matchCtx, cancelCtx := context.WithTimeout(context.Background(), time.Duration(cfg.JailWallTimeoutMS)*time.Millisecond)
+	defer cancelCtx()
+
+	s, err := m.openSandbox(matchCtx, cfg, m.Player1Dir)
+	if err != nil {
+		report.Crash = (&PlayerError{PlayerOne, "sandbox", err}).Error()
+		return report, nil
+	}
+	defer s.Destroy()
+
+	stderr := &stderrCollector{done: make(chan struct{})}
+	go stderr.collect(matchCtx, s, m.gl, "p1")
+
+	// tracebacks are read once the sandbox is gone and stderr is complete
+	defer func() {
+		select {
+		case <-stderr.done:
+		case <-time.After(stderrGrace):
+		}
+		s.Destroy()
+		<-stderr.done
+
+		report.Tracebacks = append(report.Tracebacks, tracebacks(stderr.String())...)
+		report.Passed = report.Handshake && report.Crash == "" && len(report.Tracebacks) == 0
+	}()
+
+	proto, err := handshakeSandbox(matchCtx, s, cfg.JailHandshakeTimeoutMS, rules)
+	if err != nil {
+		report.Crash = (&PlayerError{PlayerOne, "handshake", err}).Error()
+		return report, nil
+	}
+	report.Handshake = true
+	report.Protocol = fmt.Sprintf("v%d/%s", proto.Version, proto.Encoding)
+
+	m.gl.Log(GameLogDebug, "Completed Handshake", report.Protocol)
+
+	for report.Turns < turns && ge.Winner == -1 {
+		tick := ge.Ticks
+
+		turnCtx, cancelTurnCtx := context.WithTimeout(matchCtx, time.Duration(cfg.JailTickTimeoutMS)*time.Millisecond)
+
+		var move PlayerMoves
+		start := time.Now()
+		err := doTurn(turnCtx, s, m.gl, "p1", proto.view(ge.GetPlayerView(PlayerOne)), &move)
+		took := float64(time.Since(start).Microseconds()) / 1000
+
+		cancelTurnCtx()
+
+		if err != nil {
+			report.Crash = (&PlayerError{PlayerOne, "turn", err}).Error()
+			break
+		}
+
+		if report.Turns == 0 {
+			report.FirstTurnMS = took
+		}
+		report.MaxTurnMS = max(report.MaxTurnMS, took)
+		report.Turns++
+
+		m.logMove(ge, PlayerOne, move)
+
+		if m.Mode == ModeSimultaneous {
+			ge.UpdateStateSimultaneous([2]PlayerMoves{move, {}})
+		} else {
+			ge.UpdateState(move)
+		}
+
+		for _, r := range ge.Results[PlayerOne] {
+			if !r.Applied || r.Code != ResultOK {
+				report.InvalidMoves = append(report.InvalidMoves, InvalidMove{Tick: tick, ActionResult: r})
+			}
+		}
+
+		// the opponent's half of the tick
+		if m.Mode != ModeSimultaneous && ge.Winner == -1 {
+			ge.UpdateState(PlayerMoves{})
+		}
+	}
+
+	return report, nil
+}
+
+// stderrCollector logs a sandbox's stderr like streamErrors and keeps the
+// start of it for the report
+type stderrCollector struct {
+	mu   sync.Mutex
+	buf  strings.Builder
+	done chan struct{}
+}
+
+func (c *stderrCollector) collect(ctx context.Context, s *sandbox.Sandbox, gl *GameLogger, label string) {
+	defer close(c.done)
+	for {
+		data, err := s.RecvError(ctx)
+		if err != nil {
+			return
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			continue
+		}
+		gl.Log(GameLogError, label, string(data))
+
+		c.mu.Lock()
+		if c.buf.Len()+len(data) <= maxStderr {
+			c.buf.Write(data)
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *stderrCollector) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.String()
+}
+
+// tracebacks picks the Python tracebacks out of stderr, each from its
+// header to the exception line, the first unindented line after it
+func tracebacks(stderr string) []string {
+	var (
+		out  []string
+		cur  []string
+		open bool
+	)
+
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.HasPrefix(line, "Traceback (most recent call last):") {
+			open, cur = true, []string{line}
+			continue
+		}
+		if !open {
+			continue
+		}
+		cur = append(cur, line)
+		if line != "" && line[0] != ' ' && line[0] != '\t' {
+			out = append(out, strings.Join(cur, "\n"))
+			open = false
+		}
+	}
+
+	// cut off by a kill or maxStderr
+	if open {
+		out = append(out, strings.Join(cur, "\n"))
+	}
+
+	return out
+}
@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Logged instead of GameLogGameView between keyframes
+const GameLogGameViewDelta GameLogType = "VIEW_DELTA"
+
+// ViewDelta turns the previous logged view into the view of Tick. Ops
+// follow JSON Patch (RFC 6902) and only use add, remove and replace.
+type ViewDelta struct {
+	Tick int       `json:"tick"`
+	Ops  []PatchOp `json:"ops"`
+}
+
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"` // JSON pointer into the view
+	Value any    `json:"value,omitempty"`
+}
+
+// ViewEncoder decides how each view goes into the game log: a full view
+// every Interval views, deltas against the previous view in between.
+// An Interval below 2 logs every view in full.
+type ViewEncoder struct {
+	Interval int
+	n        int
+	prev     any
+}
+
+func NewViewEncoder(interval int) *ViewEncoder {
+	return &ViewEncoder{Interval: interval}
+}
+
+// Encode returns the log type and message for the next view
+func (e *ViewEncoder) Encode(view GameViewDTO) (GameLogType, any, error) {
+	keyframe := e.Interval < 2 || e.n%e.Interval == 0
+	e.n++
+
+	if e.Interval < 2 {
+		return GameLogGameView, view, nil
+	}
+
+	cur, err := toTree(view)
+	if err != nil {
+		e.prev = nil // next view becomes a keyframe
+		return "", nil, err
+	}
+
+	prev := e.prev
+	e.prev = cur
+
+	if keyframe || prev == nil {
+		return GameLogGameView, view, nil
+	}
+
+	return GameLogGameViewDelta, ViewDelta{Tick: view.Tick, Ops: diff("", prev, cur, nil)}, nil
+}
+
+// ViewDecoder rebuilds full views from keyframes and deltas, in log order
+type ViewDecoder struct {
+	cur any
+}
+
+// Apply feeds the message of a VIEW or VIEW_DELTA log entry and returns the full view it stands for
+func (d *ViewDecoder) Apply(typ GameLogType, msg json.RawMessage) (GameViewDTO, error) {
+	var view GameViewDTO
+
+	switch typ {
+	case GameLogGameView:
+		var tree any
+		if err := json.Unmarshal(msg, &tree); err != nil {
+			return view, fmt.Errorf("parse view: %w", err)
+		}
+		d.cur = tree
+
+	case GameLogGameViewDelta:
+		if d.cur == nil {
+			return view, errors.New("delta before the first keyframe")
+		}
+
+		var delta ViewDelta
+		if err := json.Unmarshal(msg, &delta); err != nil {
+			return view, fmt.Errorf("parse delta: %w", err)
+		}
+
+		for _, op := range delta.Ops {
+			cur, err := applyOp(d.cur, op)
+			if err != nil {
+				return view, fmt.Errorf("tick %d: %w", delta.Tick, err)
+			}
+			d.cur = cur
+		}
+
+	default:
+		return view, fmt.Errorf("not a view entry: %s", typ)
+	}
+
+	b, err := json.Marshal(d.cur)
+	if err != nil {
+		return view, err
+	}
+
+	err = json.Unmarshal(b, &view)
+	return view, err
+}
+
+// ReplayViews reads a game log and calls fn with the full view of every logged tick
+func ReplayViews(r io.Reader, fn func(GameViewDTO) error) error {
+	return replay(r, fn, nil)
+}
+
+// ReplayTick is a full view and the other log entries logged after it
+type ReplayTick struct {
+	View GameViewDTO       `json:"view"`
+	Logs []json.RawMessage `json:"logs"`
+}
+
+// Replay is a whole game log with every view decoded in full
+type Replay struct {
+	Header []json.RawMessage `json:"header"` // entries before the first view
+	Ticks  []ReplayTick      `json:"ticks"`
+}
+
+// ReadReplay reads a whole game log, keeping each entry next to the tick it was logged in
+func ReadReplay(r io.Reader) (*Replay, error) {
+	rp := &Replay{
+		Header: make([]json.RawMessage, 0),
+		Ticks:  make([]ReplayTick, 0),
+	}
+
+	err := replay(r, func(view GameViewDTO) error {
+		rp.Ticks = append(rp.Ticks, ReplayTick{View: view, Logs: make([]json.RawMessage, 0)})
+		return nil
+	}, func(line []byte) {
+		entry := json.RawMessage(slices.Clone(line))
+		if n := len(rp.Ticks); n > 0 {
+			rp.Ticks[n-1].Logs = append(rp.Ticks[n-1].Logs, entry)
+		} else {
+			rp.Header = append(rp.Header, entry)
+		}
+	})
+
+	return rp, err
+}
+
+// replay calls onView for view entries and onOther, when set, for the raw line of any other entry
+func replay(r io.Reader, onView func(GameViewDTO) error, onOther func(line []byte)) error {
+	var d ViewDecoder
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for sc.Scan() {
+		var entry struct {
+			Typ GameLogType       `json:"typ"`
+			Msg []json.RawMessage `json:"msg"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			return fmt.Errorf("parse log line: %w", err)
+		}
+
+		if entry.Typ != GameLogGameView && entry.Typ != GameLogGameViewDelta {
+			if onOther != nil {
+				onOther(sc.Bytes())
+			}
+			continue
+		}
+		if len(entry.Msg) == 0 {
+			return fmt.Errorf("empty %s entry", entry.Typ)
+		}
+
+		view, err := d.Apply(entry.Typ, entry.Msg[0])
+		if err != nil {
+			return err
+		}
+
+		if err := onView(view); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+var errFound = errors.New("found")
+
+// ViewAt reconstructs the full view of tick from a game log
+func ViewAt(r io.Reader, tick int) (GameViewDTO, error) {
+	var found GameViewDTO
+
+	err := ReplayViews(r, func(view GameViewDTO) error {
+		if view.Tick == tick {
+			found = view
+			return errFound
+		}
+		return nil
+	})
+
+	if errors.Is(err, errFound) {
+		return found, nil
+	}
+	if err != nil {
+		return found, err
+	}
+	return found, fmt.Errorf("tick %d not in log", tick)
+}
+
+func toTree(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree any
+	err = json.Unmarshal(b, &tree)
+	return tree, err
+}
+
+// diff appends the ops turning a into b, both decoded JSON
+func diff(path string, a, b any, ops []PatchOp) []PatchOp {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			p := path + "/" + escapePointer(k)
+			ae, inA := av[k]
+			be, inB := bv[k]
+			switch {
+			case !inB:
+				ops = append(ops, PatchOp{Op: "remove", Path: p})
+			case !inA:
+				ops = append(ops, PatchOp{Op: "add", Path: p, Value: be})
+			default:
+				ops = diff(p, ae, be, ops)
+			}
+		}
+		return ops
+
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+
+		if ka, kb := arrayKeys(av), arrayKeys(bv); ka != nil && kb != nil {
+			return diffKeyed(path, av, bv, ka, kb, ops)
+		}
+
+		common := min(len(av), len(bv))
+		for i := range common {
+			ops = diff(path+"/"+strconv.Itoa(i), av[i], bv[i], ops)
+		}
+		// remove from the back so earlier indices stay valid
+		for i := len(av) - 1; i >= common; i-- {
+			ops = append(ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := common; i < len(bv); i++ {
+			ops = append(ops, PatchOp{Op: "add", Path: path + "/-", Value: bv[i]})
+		}
+		return ops
+
+	default:
+		if a == b {
+			return ops
+		}
+	}
+
+	return append(ops, PatchOp{Op: "replace", Path: path, Value: b})
+}
+
+// arrayKeys returns a key per element when every element is an object with
+// a unique "id" or "location", like bots and algae tiles, and nil otherwise
+func arrayKeys(arr []any) []string {
+	if len(arr) == 0 {
+		return []string{}
+	}
+
+	keys := make([]string, len(arr))
+	seen := make(map[string]bool, len(arr))
+	for i, e := range arr {
+		obj, ok := e.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if id, ok := obj["id"]; ok {
+			keys[i] = fmt.Sprint("id=", id)
+		} else if loc, ok := obj["location"]; ok {
+			keys[i] = fmt.Sprint("location=", loc)
+		} else {
+			return nil
+		}
+		if seen[keys[i]] {
+			return nil
+		}
+		seen[keys[i]] = true
+	}
+	return keys
+}
+
+// diffKeyed diffs arrays element by key instead of by index, so removing an
+// element near the front costs one op instead of shifting every later one
+func diffKeyed(path string, a, b []any, ka, kb []string, ops []PatchOp) []PatchOp {
+	inB := make(map[string]bool, len(kb))
+	for _, k := range kb {
+		inB[k] = true
+	}
+
+	// drop what b no longer has, from the back so earlier indices stay valid
+	kept := make([]int, 0, len(a))
+	for i := len(a) - 1; i >= 0; i-- {
+		if !inB[ka[i]] {
+			ops = append(ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		} else {
+			kept = append(kept, i)
+		}
+	}
+	slices.Reverse(kept)
+
+	// then build b front to back from the kept elements, adding the new ones
+	k := 0
+	for j := range b {
+		p := path + "/" + strconv.Itoa(j)
+		if k < len(kept) && ka[kept[k]] == kb[j] {
+			ops = diff(p, a[kept[k]], b[j], ops)
+			k++
+			continue
+		}
+		if k == len(kept) {
+			p = path + "/-"
+		}
+		ops = append(ops, PatchOp{Op: "add", Path: p, Value: b[j]})
+	}
+
+	// kept elements out of b's order were added again above, drop the originals
+	for i := len(b) + len(kept) - k - 1; i >= len(b); i-- {
+		ops = append(ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	return ops
+}
+
+func applyOp(doc any, op PatchOp) (any, error) {
+	if op.Path == "" {
+		if op.Op == "remove" {
+			return nil, nil
+		}
+		return op.Value, nil
+	}
+
+	tokens := strings.Split(op.Path, "/")[1:]
+	for i, t := range tokens {
+		tokens[i] = unescapePointer(t)
+	}
+
+	// walk to the parent container
+	parent := doc
+	for _, t := range tokens[:len(tokens)-1] {
+		switch c := parent.(type) {
+		case map[string]any:
+			parent = c[t]
+		case []any:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("bad index %q in %s", t, op.Path)
+			}
+			parent = c[i]
+		default:
+			return nil, fmt.Errorf("path %s does not exist", op.Path)
+		}
+	}
+
+	last := tokens[len(tokens)-1]
+
+	switch c := parent.(type) {
+	case map[string]any:
+		if op.Op == "remove" {
+			delete(c, last)
+		} else {
+			c[last] = op.Value
+		}
+		return doc, nil
+
+	case []any:
+		var updated []any
+
+		if op.Op == "add" && last == "-" {
+			updated = append(c, op.Value)
+		} else {
+			i, err := strconv.Atoi(last)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("bad index %q in %s", last, op.Path)
+			}
+			switch op.Op {
+			case "remove":
+				updated = slices.Delete(c, i, i+1)
+			case "replace":
+				c[i] = op.Value
+				return doc, nil
+			default:
+				updated = slices.Insert(c, i, op.Value)
+			}
+		}
+
+		// slices are values, so the grown or shrunk one goes back into its parent
+		return applyOp(doc, PatchOp{Op: "replace", Path: op.Path[:strings.LastIndex(op.Path, "/")], Value: updated})
+	}
+
+	return nil, fmt.Errorf("path %s does not exist", op.Path)
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,277 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// playLogged plays ticks half-turns on the default board, logging every view
+// with interval like Match does, and returns the log and the views it saw.
+// Both players spawn a bot on each of their first turns, every bot moves
+// east, and bots are killed on some ticks so views also lose bots.
+func playLogged(t *testing.T, ticks, interval int, kills map[int]bool) (*bytes.Buffer, []GameViewDTO) {
+	t.Helper()
+
+	var log bytes.Buffer
+	gl := NewGameLogger(&log)
+	ge := NewSeededGameEngine(gl, DefaultMap(), DefaultRuleset(), 7)
+	views := NewViewEncoder(interval)
+
+	var want []GameViewDTO
+	for range ticks {
+		view := ge.GameView()
+		typ, msg, err := views.Encode(view)
+		if err != nil {
+			t.Fatalf("tick %d: encode: %v", view.Tick, err)
+		}
+		gl.Log(typ, msg)
+		want = append(want, view)
+
+		playerID := ge.currentPlayerID()
+		move := PlayerMoves{Spawns: map[int]SpawnCmd{}, Actions: map[int]ActionCmd{}}
+		if ge.Ticks <= 8 {
+			id := ge.BotIDSeed[playerID] + ge.Ticks
+			move.Spawns[id] = SpawnCmd{Abilities: []string{"HARVEST"}, Location: Point{X: 0, Y: 2 * ge.Ticks}}
+		}
+		for id, bot := range ge.AllBots {
+			if bot.OwnerID == playerID {
+				move.Actions[id] = ActionCmd{Action: "MOVE", Direction: "EAST"}
+			}
+		}
+		ge.UpdateState(move)
+
+		if kills[ge.Ticks] {
+			for _, id := range sortedKeys(ge.AllBots) {
+				ge.KillBot(id)
+				break
+			}
+		}
+	}
+
+	return &log, want
+}
+
+func sameJSON(t *testing.T, got, want any) bool {
+	t.Helper()
+
+	g, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Equal(g, w)
+}
+
+func TestViewLogRoundTrip(t *testing.T) {
+	const interval = 4
+
+	// kills land on a keyframe tick, on the tick right after one, and between
+	kills := map[int]bool{13: true, 14: true, 22: true, 31: true}
+	log, want := playLogged(t, 40, interval, kills)
+
+	// keyframes exactly every interval views, deltas in between
+	var types []GameLogType
+	for _, line := range bytes.Split(bytes.TrimSpace(log.Bytes()), []byte("\n")) {
+		var entry GameLog
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, entry.Typ)
+	}
+	views := 0
+	for _, typ := range types {
+		if typ != GameLogGameView && typ != GameLogGameViewDelta {
+			continue
+		}
+		wantTyp := GameLogGameViewDelta
+		if views%interval == 0 {
+			wantTyp = GameLogGameView
+		}
+		if typ != wantTyp {
+			t.Errorf("view %d logged as %s, want %s", views, typ, wantTyp)
+		}
+		views++
+	}
+	if views != len(want) {
+		t.Fatalf("logged %d views, want %d", views, len(want))
+	}
+
+	var got []GameViewDTO
+	err := ReplayViews(bytes.NewReader(log.Bytes()), func(v GameViewDTO) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d views, want %d", len(got), len(want))
+	}
+
+	removed := 0
+	for i := range want {
+		if !sameJSON(t, got[i], want[i]) {
+			t.Errorf("tick %d: replayed view differs from the played one", want[i].Tick)
+		}
+		if i > 0 {
+			for id := range want[i-1].AllBots {
+				if _, ok := want[i].AllBots[id]; !ok {
+					removed++
+				}
+			}
+		}
+	}
+	if removed == 0 {
+		t.Error("no view lost a bot, the test does not cover removed bots")
+	}
+
+	// a delta tick rebuilt on its own
+	v, err := ViewAt(bytes.NewReader(log.Bytes()), want[14].Tick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJSON(t, v, want[14]) {
+		t.Errorf("ViewAt(%d) differs from the played view", want[14].Tick)
+	}
+}
+
+// every view in full must give the same views as keyframes and deltas
+func TestViewLogIntervals(t *testing.T) {
+	kills := map[int]bool{5: true, 9: true}
+	full, want := playLogged(t, 20, 0, kills)
+
+	for _, interval := range []int{2, 3, 20, 50} {
+		log, _ := playLogged(t, 20, interval, kills)
+
+		i := 0
+		err := ReplayViews(bytes.NewReader(log.Bytes()), func(v GameViewDTO) error {
+			if !sameJSON(t, v, want[i]) {
+				t.Errorf("interval %d, tick %d: replayed view differs", interval, v.Tick)
+			}
+			i++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("interval %d: %v", interval, err)
+		}
+		if i != len(want) {
+			t.Errorf("interval %d: replayed %d views, want %d", interval, i, len(want))
+		}
+	}
+
+	if bytes.Contains(full.Bytes(), []byte(GameLogGameViewDelta)) {
+		t.Error("interval 0 logged a delta")
+	}
+}
+
+func TestDiffApply(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"same", `{"a":1,"b":[1,2]}`, `{"a":1,"b":[1,2]}`},
+		{"replace", `{"a":1}`, `{"a":2}`},
+		{"add and remove keys", `{"a":1,"b":2}`, `{"b":2,"c":3}`},
+		{"escaped keys", `{"a/b":1,"c~d":2}`, `{"a/b":3,"e~/f":4}`},
+		{"array grows", `{"a":[1]}`, `{"a":[1,2,3]}`},
+		{"array shrinks", `{"a":[1,2,3]}`, `{"a":[1]}`},
+		{"array to empty", `{"a":[1,2]}`, `{"a":[]}`},
+		{"nested", `{"a":{"b":{"c":1,"d":[{"e":1}]}}}`, `{"a":{"b":{"c":2,"d":[{"e":2},{"f":3}]}}}`},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1,2]}`},
+		{"to null", `{"a":{"b":1}}`, `{"a":null}`},
+		{"from null", `{"a":null}`, `{"a":{"b":1}}`},
+		{"whole doc", `[1,2]`, `{"a":1}`},
+		{"keyed remove at the front", `{"a":[{"id":1},{"id":2},{"id":3}]}`, `{"a":[{"id":2},{"id":3}]}`},
+		{"keyed insert and change", `{"a":[{"id":1,"v":1},{"id":3}]}`, `{"a":[{"id":1,"v":2},{"id":2},{"id":3},{"id":4}]}`},
+		{"keyed reorder", `{"a":[{"id":1},{"id":2},{"id":3}]}`, `{"a":[{"id":3},{"id":1},{"id":2}]}`},
+		{"keyed replace all", `{"a":[{"id":1},{"id":2}]}`, `{"a":[{"id":3}]}`},
+		{"keyed from empty", `{"a":[]}`, `{"a":[{"id":1},{"id":2}]}`},
+		{"keyed by location", `{"a":[{"location":{"x":0,"y":0}},{"location":{"x":1,"y":0}}]}`, `{"a":[{"location":{"x":1,"y":0}},{"location":{"x":2,"y":0}}]}`},
+		{"duplicate keys by index", `{"a":[{"id":1,"v":1},{"id":1,"v":2}]}`, `{"a":[{"id":1,"v":2}]}`},
+		{"partly keyed by index", `{"a":[{"id":1},{"v":2}]}`, `{"a":[{"v":2}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b, doc any
+			if err := json.Unmarshal([]byte(tt.a), &a); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.b), &b); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.a), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			ops := diff("", a, b, nil)
+			if tt.a == tt.b && len(ops) != 0 {
+				t.Errorf("equal docs gave ops %v", ops)
+			}
+
+			for _, op := range ops {
+				// ops go through the log as JSON
+				raw, err := json.Marshal(op)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var logged PatchOp
+				if err := json.Unmarshal(raw, &logged); err != nil {
+					t.Fatal(err)
+				}
+
+				doc, err = applyOp(doc, logged)
+				if err != nil {
+					t.Fatalf("apply %v: %v", logged, err)
+				}
+			}
+
+			if !reflect.DeepEqual(doc, b) {
+				t.Errorf("got %v, want %v (ops %v)", doc, b, ops)
+			}
+		})
+	}
+}
+
+// keyed array elements are diffed in place however far they shifted
+func TestDiffKeyedOps(t *testing.T) {
+	algae := func(xs ...int) []any {
+		arr := make([]any, 0, len(xs))
+		for _, x := range xs {
+			arr = append(arr, map[string]any{"location": map[string]any{"x": float64(x), "y": 0.0}, "is_poison": "UNKNOWN"})
+		}
+		return arr
+	}
+
+	tests := []struct {
+		name string
+		a, b []any
+		want []PatchOp
+	}{
+		{"harvested at the front", algae(0, 1, 2, 3, 4), algae(1, 2, 3, 4), []PatchOp{{Op: "remove", Path: "/0"}}},
+		{"harvested in the middle", algae(0, 1, 2, 3, 4), algae(0, 1, 3, 4), []PatchOp{{Op: "remove", Path: "/2"}}},
+		{"new tile in the middle", algae(0, 1, 3), algae(0, 1, 2, 3), []PatchOp{{Op: "add", Path: "/2", Value: algae(2)[0]}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := diff("", tt.a, tt.b, nil)
+			if !reflect.DeepEqual(ops, tt.want) {
+				t.Errorf("got ops %v, want %v", ops, tt.want)
+			}
+		})
+	}
+
+	scouted := algae(0, 1, 2)
+	scouted[1].(map[string]any)["is_poison"] = "TRUE"
+	ops := diff("", algae(0, 1, 2), scouted, nil)
+	want := []PatchOp{{Op: "replace", Path: "/1/is_poison", Value: "TRUE"}}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("scouted: got ops %v, want %v", ops, want)
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+// visibility marks, per tile, what a player's bots can see and which tiles
+// a scout can tell poison on. Both are indexed [x][y] like the grid.
+func (engine *GameEngine) visibility(playerID int) (canSee [][]bool, canScout [][]bool) {
+	canSee = engine.newMask(!engine.Rules.FogOfWar)
+	canScout = engine.newMask(false)
+
+	for _, bot := range engine.AllBots {
+		if bot.OwnerID != playerID {
+			continue
+		}
+
+		// a scout also sees everything it scouts
+		isScout := engine.hasAbility(bot.ID, "SCOUT")
+		radius := bot.VisionRadius
+		if isScout {
+			radius = max(radius, engine.Rules.ScoutRadius)
+		}
+
+		minX := max(0, bot.Location.X-radius)
+		maxX := min(engine.Width-1, bot.Location.X+radius)
+		minY := max(0, bot.Location.Y-radius)
+		maxY := min(engine.Height-1, bot.Location.Y+radius)
+
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				dist := manhattanDist(bot.Location.X, bot.Location.Y, x, y)
+				seen := engine.canView(bot.Location, Point{x, y})
+
+				if dist <= radius && seen {
+					canSee[x][y] = true
+				}
+				if isScout && dist <= engine.Rules.ScoutRadius && seen {
+					canScout[x][y] = true
+				}
+			}
+		}
+	}
+
+	return canSee, canScout
+}
+
+func (engine *GameEngine) newMask(value bool) [][]bool {
+	mask := make([][]bool, engine.Width)
+	for x := range mask {
+		mask[x] = make([]bool, engine.Height)
+		if value {
+			for y := range mask[x] {
+				mask[x][y] = true
+			}
+		}
+	}
+	return mask
+}
+
+// canView reports whether nothing blocks the view from one tile to another.
+// Without fog of war or wall occlusion nothing ever does.
+func (engine *GameEngine) canView(from Point, to Point) bool {
+	if !engine.Rules.FogOfWar || !engine.Rules.WallsBlockVision {
+		return true
+	}
+	return engine.lineOfSight(from, to)
+}
+
+// lineOfSight walks the Bresenham line between the tiles; a wall between
+// them blocks the view, a wall at either end is itself visible
+func (engine *GameEngine) lineOfSight(from Point, to Point) bool {
+	dx := absDiffInt(from.X, to.X)
+	dy := -absDiffInt(from.Y, to.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+
+	p := from
+	e := dx + dy
+	for p != to {
+		if p != from && engine.isWall(p) {
+			return false
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p.Y += sy
+		}
+	}
+	return true
+}
@@ -1,44 +1,190 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/delta/code-runner/internal/config"
 	"github.com/delta/code-runner/internal/engine"
+	"github.com/delta/code-runner/internal/sandbox"
+	"github.com/delta/code-runner/internal/spectate"
 )
 
 type GameManager struct {
-	cfg     *config.Config
+	cfg atomic.Pointer[config.Config]
+	// jobs running with each config, and what to call once a replaced
+	// config has none left
+	jobs     map[*config.Config]int
+	releases map[*config.Config]func()
+
 	matches map[string]*engine.Match
+	pool    *sandbox.Pool // nil unless WARM_POOL is enabled
+	live    *spectate.Hub
 	mu      sync.Mutex
 }
 
 func NewGameManager(cfg *config.Config) *GameManager {
-	return &GameManager{
-		cfg:     cfg,
-		matches: make(map[string]*engine.Match),
-		mu:      sync.Mutex{},
+	gm := &GameManager{
+		jobs:     make(map[*config.Config]int),
+		releases: make(map[*config.Config]func()),
+		matches:  make(map[string]*engine.Match),
+		live:     spectate.NewHub(),
+		mu:       sync.Mutex{},
+	}
+	gm.cfg.Store(cfg)
+
+	if cfg.WarmPool {
+		gm.pool = sandbox.NewPool(poolConfig(cfg))
+	}
+
+	return gm
+}
+
+// SetConfig swaps the config used by matches started from now on.
+// Matches already running keep the config they started with. Once cfg has
+// been replaced in turn and its last job has ended, release is called (when
+// not nil) so files written for it can be removed.
+func (gm *GameManager) SetConfig(cfg *config.Config, release func()) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	prev := gm.cfg.Swap(cfg)
+	if release != nil {
+		gm.releases[cfg] = release
+	}
+	if gm.jobs[prev] == 0 {
+		gm.retire(prev)
+	}
+
+	switch {
+	case cfg.WarmPool && gm.pool == nil:
+		gm.pool = sandbox.NewPool(poolConfig(cfg))
+	case cfg.WarmPool:
+		gm.pool.Reconfigure(poolConfig(cfg))
+	case gm.pool != nil:
+		gm.pool.Close()
+		gm.pool = nil
 	}
 }
 
+func (gm *GameManager) Close() {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if gm.pool != nil {
+		gm.pool.Close()
+	}
+}
+
+// two jails per match
+func poolConfig(cfg *config.Config) sandbox.PoolConfig {
+	return sandbox.PoolConfig{
+		NsjailPath:         cfg.NsjailPath,
+		NsjailWarmCfgPath:  cfg.NsjailWarmCfgPath,
+		SlotRoot:           cfg.HostWarmPath,
+		JailSubmissionPath: cfg.JailSubmissionPath,
+		Size:               2 * cfg.MaxConcurrentMatches,
+	}
+}
+
+func (gm *GameManager) Config() *config.Config {
+	return gm.cfg.Load()
+}
+
+// useConfig returns the current config for a job, which hands it back with
+// doneConfig when it ends
+func (gm *GameManager) useConfig() *config.Config {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	cfg := gm.cfg.Load()
+	gm.jobs[cfg]++
+	return cfg
+}
+
+func (gm *GameManager) doneConfig(cfg *config.Config) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	gm.jobs[cfg]--
+	if gm.jobs[cfg] == 0 && cfg != gm.cfg.Load() {
+		gm.retire(cfg)
+	}
+}
+
+// retire forgets a replaced config without jobs, gm.mu is held
+func (gm *GameManager) retire(cfg *config.Config) {
+	delete(gm.jobs, cfg)
+	if release, ok := gm.releases[cfg]; ok {
+		delete(gm.releases, cfg)
+		release()
+	}
+}
+
+// Spectators streams the running matches, see spectate.Hub.Register
+func (gm *GameManager) Spectators() *spectate.Hub {
+	return gm.live
+}
+
+const (
+	JobMatch    = "MATCH"
+	JobValidate = "VALIDATE" // smoke test of P1Code against engine.BuiltinOpponent, P2 is not used
+)
+
 type MatchJob struct {
-	ID     string `json:"id"`
-	P1     string `json:"p1"`
-	P2     string `json:"p2"`
-	P1Code string `json:"p1_code"`
-	P2Code string `json:"p2_code"`
+	Type    string `json:"type,omitempty"` // JobMatch (default) or JobValidate
+	ID      string `json:"id"`
+	P1      string `json:"p1"`
+	P2      string `json:"p2"`
+	P1Code  string `json:"p1_code"`
+	P2Code  string `json:"p2_code"`
+	Mode    string `json:"mode,omitempty"`    // ALTERNATE (default) or SIMULTANEOUS
+	Map     string `json:"map,omitempty"`     // map file name, "default" when empty
+	Ruleset string `json:"ruleset,omitempty"` // ruleset file name, "default" when empty
+	// the ruleset itself, pinned by the producer when the job was queued so
+	// later edits of the file do not change it. Used instead of the file when set.
+	Rules json.RawMessage `json:"rules,omitempty"`
+	Seed  int64           `json:"seed,omitempty"`  // board seed of the first game, random when 0
+	Games int             `json:"games,omitempty"` // best of this many games with sides swapped, one game when 0 or 1
+	Turns int             `json:"turns,omitempty"` // turns of a validation, at most and by default VALIDATION_TURNS
+}
+
+// game is one game of a job, with the players in seat order
+type game struct {
+	id       string
+	dir      string // holds log.txt and result.json
+	players  [2]string
+	codes    [2]string
+	codeDirs [2]string
+	seed     int64
 }
 
 func (gm *GameManager) NewMatch(job MatchJob) error {
-	p1Dir := path.Join(gm.cfg.HostSubmissionPath, job.ID, "p1")
-	p2Dir := path.Join(gm.cfg.HostSubmissionPath, job.ID, "p2")
-	logFile := path.Join(gm.cfg.HostSubmissionPath, job.ID, "log.txt")
+	cfg := gm.useConfig()
+	defer gm.doneConfig(cfg)
+
+	switch job.Type {
+	case "", JobMatch:
+	case JobValidate:
+		return gm.validate(cfg, job)
+	default:
+		return fmt.Errorf("unknown job type %q", job.Type)
+	}
+
+	if job.Games > MaxSeriesGames {
+		return fmt.Errorf("best of %d games, at most %d allowed", job.Games, MaxSeriesGames)
+	}
+
+	jobDir := path.Join(cfg.HostSubmissionPath, job.ID)
+	p1Dir := path.Join(jobDir, "p1")
+	p2Dir := path.Join(jobDir, "p2")
 
 	if err := os.MkdirAll(p1Dir, 0700); err != nil {
 		return fmt.Errorf("mkdir p1: %w", err)
@@ -50,47 +196,98 @@ func (gm *GameManager) NewMatch(job MatchJob) error {
 	}
 	defer os.RemoveAll(p2Dir)
 
+	g := game{
+		id:       job.ID,
+		dir:      jobDir,
+		players:  [2]string{job.P1, job.P2},
+		codes:    [2]string{job.P1Code, job.P2Code},
+		codeDirs: [2]string{p1Dir, p2Dir},
+		seed:     job.Seed,
+	}
+
+	if job.Games > 1 {
+		return gm.playSeries(cfg, job, g)
+	}
+
+	_, err := gm.playGame(cfg, job, g)
+	return err
+}
+
+// playGame plays g and logs any error to its game log. The record is nil when
+// the game could not be set up, otherwise it is also saved as result.json.
+func (gm *GameManager) playGame(cfg *config.Config, job MatchJob, g game) (*engine.MatchRecord, error) {
+	if err := os.MkdirAll(g.dir, 0700); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+
+	logFile := path.Join(g.dir, "log.txt")
+	resultFile := path.Join(g.dir, "result.json")
+
 	logF, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return fmt.Errorf("create log file: %w", err)
+		return nil, fmt.Errorf("create log file: %w", err)
 	}
 	defer logF.Close()
 
 	gl := engine.NewGameLogger(logF)
 
-	gl.Log(engine.GameLogDebug, fmt.Sprintf("Match ID %s at %s", job.ID, time.Now().Format(time.RFC3339)))
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Match ID %s at %s", g.id, time.Now().Format(time.RFC3339)))
+
+	gameMap, rules, err := loadBoard(cfg, job, gl)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := savePlayerCode(job.P1Code, path.Join(p1Dir, "submission.py")); err != nil {
+	if err := savePlayerCode(g.codes[engine.PlayerOne], path.Join(g.codeDirs[engine.PlayerOne], "submission.py")); err != nil {
 		err = fmt.Errorf("save p1 code: %w", err)
 		gl.Log(engine.GameLogError, err.Error())
-		return err
+		return nil, err
 	}
 
-	if err := savePlayerCode(job.P2Code, path.Join(p2Dir, "submission.py")); err != nil {
+	if err := savePlayerCode(g.codes[engine.PlayerTwo], path.Join(g.codeDirs[engine.PlayerTwo], "submission.py")); err != nil {
 		err = fmt.Errorf("save p2 code: %w", err)
 		gl.Log(engine.GameLogError, err.Error())
-		return err
+		return nil, err
+	}
+
+	m := engine.NewMatch(g.id, g.players[engine.PlayerOne], g.players[engine.PlayerTwo], g.codeDirs[engine.PlayerOne], g.codeDirs[engine.PlayerTwo], gl)
+
+	if job.Mode != "" {
+		m.Mode = job.Mode
 	}
+	m.Map = gameMap
+	m.Rules = rules
+	m.Seed = g.seed
 
-	m := engine.NewMatch(job.ID, job.P1, job.P2, p1Dir, p2Dir, gl)
+	feed := gm.live.Open(g.id)
+	defer gm.live.Close(g.id, feed)
+	m.Spectators = feed
 
 	gm.mu.Lock()
-	gm.matches[job.ID] = m
+	m.Pool = gm.pool
+	gm.matches[g.id] = m
 	gm.mu.Unlock()
 
 	defer func() {
 		gm.mu.Lock()
-		delete(gm.matches, job.ID)
+		delete(gm.matches, g.id)
 		gm.mu.Unlock()
 	}()
 
 	gl.Log(engine.GameLogDebug, "Completed Setup")
 
-	err = m.Simulate(gm.cfg)
+	err = m.Simulate(cfg)
+
+	// kept for failed matches too, a forfeit still counts for ratings
+	rec := m.Record(err)
+	if recErr := saveRecord(rec, resultFile); recErr != nil {
+		gl.Log(engine.GameLogWarn, "save result:", recErr.Error())
+	}
+
 	if err != nil {
 		err = fmt.Errorf("simulate: %w", err)
 		gl.Log(engine.GameLogError, err.Error())
-		return err
+		return &rec, err
 	}
 
 	gl.Log(engine.GameLogDebug, "Completed Simulation")
@@ -98,12 +295,61 @@ func (gm *GameManager) NewMatch(job MatchJob) error {
 	if err := UploadFile(m.ID, logFile); err != nil {
 		err = fmt.Errorf("upload log file: %w", err)
 		gl.Log(engine.GameLogError, err.Error())
-		return err
+		return &rec, err
 	}
 
 	gl.Log(engine.GameLogDebug, "Completed Post-Simulation Operations")
 
-	return nil
+	return &rec, nil
+}
+
+// loadBoard loads the map and ruleset of job and logs them, or the error
+func loadBoard(cfg *config.Config, job MatchJob, gl *engine.GameLogger) (*engine.GameMap, *engine.Ruleset, error) {
+	gameMap, err := engine.LoadMap(cfg.MapsPath, job.Map)
+	if err != nil {
+		err = fmt.Errorf("load map: %w", err)
+		gl.Log(engine.GameLogError, err.Error())
+		return nil, nil, err
+	}
+
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Map %s", gameMap.Name))
+
+	rules, err := jobRuleset(cfg, job)
+	if err != nil {
+		err = fmt.Errorf("load ruleset: %w", err)
+		gl.Log(engine.GameLogError, err.Error())
+		return nil, nil, err
+	}
+
+	// the full ruleset goes into the log so a replay never depends on the file
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Ruleset %s", rules.Name), rules)
+
+	return gameMap, rules, nil
+}
+
+// jobRuleset is the ruleset pinned in job, or else the current file it names
+func jobRuleset(cfg *config.Config, job MatchJob) (*engine.Ruleset, error) {
+	if len(job.Rules) == 0 {
+		return engine.LoadRuleset(cfg.RulesetsPath, job.Ruleset)
+	}
+
+	rules, err := engine.ParseRuleset(job.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("pinned: %w", err)
+	}
+	if job.Ruleset != "" && job.Ruleset != rules.Name {
+		return nil, fmt.Errorf("pinned ruleset is %q, job names %q", rules.Name, job.Ruleset)
+	}
+
+	return rules, nil
+}
+
+func saveRecord(rec engine.MatchRecord, dst string) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
 }
 
 func savePlayerCode(s string, dst string) error {
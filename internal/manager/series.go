@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// MaxSeriesGames bounds a series, which holds its delivery and runner slot
+// until the last game
+const MaxSeriesGames = 9
+
+// SeriesResult sums up a best-of-N job. Unlike the records of its games it
+// counts by job player, not by seat: index 0 is the job's P1.
+type SeriesResult struct {
+	ID       string               `json:"id"`
+	P1       string               `json:"p1"`
+	P2       string               `json:"p2"`
+	BestOf   int                  `json:"best_of"`
+	Games    []engine.MatchRecord `json:"games"` // in play order, ID <job id>-g<n>
+	Wins     [2]int               `json:"wins"`  // forfeits included
+	Draws    int                  `json:"draws"`
+	Algae    [2]int               `json:"algae"`              // deposited over all games, the first tiebreaker
+	Scraps   [2]int               `json:"scraps"`             // left over all games, the second tiebreaker
+	Winner   int                  `json:"winner"`             // 0 for P1, 1 for P2 or engine.Draw
+	Tiebreak string               `json:"tiebreak,omitempty"` // algae or scraps when the wins were level
+}
+
+// playSeries plays up to job.Games games, P1 takes the first seat in odd
+// games and the second one in even games, and every game gets its own board
+// seed. The series stops once the trailing side cannot catch up. Games that
+// fail only end up in the result, the job fails when one cannot be set up.
+func (gm *GameManager) playSeries(cfg *config.Config, job MatchJob, first game) error {
+	seed := job.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	sr := &SeriesResult{
+		ID:     job.ID,
+		P1:     job.P1,
+		P2:     job.P2,
+		BestOf: job.Games,
+		Games:  make([]engine.MatchRecord, 0, job.Games),
+	}
+
+	for i := range job.Games {
+		g := first
+		g.id = fmt.Sprintf("%s-g%d", job.ID, i+1)
+		g.dir = path.Join(cfg.HostSubmissionPath, g.id)
+		g.seed = seed + int64(i)
+
+		swapped := i%2 == 1
+		if swapped {
+			g.players[0], g.players[1] = g.players[1], g.players[0]
+			g.codes[0], g.codes[1] = g.codes[1], g.codes[0]
+			g.codeDirs[0], g.codeDirs[1] = g.codeDirs[1], g.codeDirs[0]
+		}
+
+		rec, err := gm.playGame(cfg, job, g)
+		if rec == nil {
+			return fmt.Errorf("game %d: %w", i+1, err)
+		}
+		if err != nil {
+			log.Printf("SERIES %s GAME %d FAILED: %v\n", job.ID, i+1, err)
+		}
+
+		sr.add(*rec, swapped)
+
+		left := job.Games - i - 1
+		if sr.Wins[0] > sr.Wins[1]+left || sr.Wins[1] > sr.Wins[0]+left {
+			break
+		}
+	}
+
+	sr.decide()
+
+	data, err := json.Marshal(sr)
+	if err != nil {
+		return fmt.Errorf("encode series result: %w", err)
+	}
+	seriesFile := path.Join(first.dir, "series.json")
+	if err := os.WriteFile(seriesFile, data, 0644); err != nil {
+		return fmt.Errorf("save series result: %w", err)
+	}
+
+	// the games are uploaded under their own IDs, the job's ID gets the summary
+	if err := UploadFile(job.ID, seriesFile); err != nil {
+		return fmt.Errorf("upload series result: %w", err)
+	}
+
+	return nil
+}
+
+func (sr *SeriesResult) add(rec engine.MatchRecord, swapped bool) {
+	sr.Games = append(sr.Games, rec)
+
+	// job player in a seat
+	player := func(seat int) int {
+		if swapped {
+			return 1 - seat
+		}
+		return seat
+	}
+
+	switch {
+	case rec.Forfeited():
+		sr.Wins[1-player(rec.Failed)]++
+		return
+	case rec.Result == nil || rec.Error != "":
+		return
+	case rec.Result.Winner == engine.Draw:
+		sr.Draws++
+	default:
+		sr.Wins[player(rec.Result.Winner)]++
+	}
+
+	for seat := range rec.Result.Algae {
+		sr.Algae[player(seat)] += rec.Result.Algae[seat]
+		sr.Scraps[player(seat)] += rec.Result.Scraps[seat]
+	}
+}
+
+func (sr *SeriesResult) decide() {
+	sr.Winner = engine.Draw
+
+	switch {
+	case sr.Wins[0] != sr.Wins[1]:
+		sr.Winner = leader(sr.Wins)
+	case sr.Algae[0] != sr.Algae[1]:
+		sr.Winner, sr.Tiebreak = leader(sr.Algae), "algae"
+	case sr.Scraps[0] != sr.Scraps[1]:
+		sr.Winner, sr.Tiebreak = leader(sr.Scraps), "scraps"
+	}
+}
+
+func leader(counts [2]int) int {
+	if counts[0] > counts[1] {
+		return 0
+	}
+	return 1
+}
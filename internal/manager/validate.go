@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// validate smoke tests job.P1Code and uploads the report as validation.json
+// next to log.txt. A submission failing the test does not fail the job, only
+// a test that could not run does.
+func (gm *GameManager) validate(cfg *config.Config, job MatchJob) error {
+	jobDir := path.Join(cfg.HostSubmissionPath, job.ID)
+	codeDir := path.Join(jobDir, "p1")
+
+	if err := os.MkdirAll(codeDir, 0700); err != nil {
+		return fmt.Errorf("mkdir p1: %w", err)
+	}
+	defer os.RemoveAll(codeDir)
+
+	logFile := path.Join(jobDir, "log.txt")
+	reportFile := path.Join(jobDir, "validation.json")
+
+	logF, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("create log file: %w", err)
+	}
+	defer logF.Close()
+
+	gl := engine.NewGameLogger(logF)
+
+	gl.Log(engine.GameLogDebug, fmt.Sprintf("Validation ID %s at %s", job.ID, time.Now().Format(time.RFC3339)))
+
+	gameMap, rules, err := loadBoard(cfg, job, gl)
+	if err != nil {
+		return err
+	}
+
+	if err := savePlayerCode(job.P1Code, path.Join(codeDir, "submission.py")); err != nil {
+		err = fmt.Errorf("save p1 code: %w", err)
+		gl.Log(engine.GameLogError, err.Error())
+		return err
+	}
+
+	m := engine.NewMatch(job.ID, job.P1, engine.BuiltinOpponent, codeDir, "", gl)
+
+	if job.Mode != "" {
+		m.Mode = job.Mode
+	}
+	m.Map = gameMap
+	m.Rules = rules
+	m.Seed = job.Seed
+
+	gm.mu.Lock()
+	m.Pool = gm.pool
+	gm.matches[job.ID] = m
+	gm.mu.Unlock()
+
+	defer func() {
+		gm.mu.Lock()
+		delete(gm.matches, job.ID)
+		gm.mu.Unlock()
+	}()
+
+	// a job may only shorten the test, a long one would hold the slot
+	turns := cfg.ValidationTurns
+	if job.Turns > 0 && job.Turns < turns {
+		turns = job.Turns
+	}
+
+	gl.Log(engine.GameLogDebug, "Completed Setup")
+
+	report, err := m.Validate(cfg, turns)
+	if err != nil {
+		err = fmt.Errorf("validate: %w", err)
+		gl.Log(engine.GameLogError, err.Error())
+		return err
+	}
+
+	if report.Passed {
+		gl.Log(engine.GameLogDebug, fmt.Sprintf("Validation passed after %d turns, first turn %.1fms", report.Turns, report.FirstTurnMS))
+	} else {
+		gl.Log(engine.GameLogWarn, "Validation failed", validationFailure(report))
+	}
+
+	// without a report the job fails, but the log still goes up to say why
+	reportErr := saveReport(reportFile, report)
+	if reportErr != nil {
+		gl.Log(engine.GameLogError, reportErr.Error())
+	} else if err := UploadFile(job.ID, reportFile); err != nil {
+		reportErr = fmt.Errorf("upload validation report: %w", err)
+		gl.Log(engine.GameLogError, reportErr.Error())
+	}
+
+	if err := UploadFile(m.ID, logFile); err != nil {
+		err = fmt.Errorf("upload log file: %w", err)
+		gl.Log(engine.GameLogError, err.Error())
+		return err
+	}
+
+	return reportErr
+}
+
+func saveReport(file string, report *engine.ValidationReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("encode validation report: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("save validation report: %w", err)
+	}
+	return nil
+}
+
+// validationFailure sums up why report did not pass
+func validationFailure(report *engine.ValidationReport) string {
+	var reasons []string
+	if report.Crash != "" {
+		reasons = append(reasons, report.Crash)
+	}
+	if n := len(report.Tracebacks); n > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d tracebacks", n))
+	}
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,51 @@
+package matchmaking
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/delta/code-runner/internal/ratings"
+)
+
+// LoadPlayers reads the active submissions from dir, one <id>.py or
+// <id>/submission.py per player, rated from table. Players missing from the
+// table, or all of them when table is nil, get the initial rating.
+func LoadPlayers(dir string, table *ratings.Table, initial float64) ([]*Player, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]*Player, 0, len(entries))
+	for _, e := range entries {
+		id, file := e.Name(), filepath.Join(dir, e.Name())
+		if e.IsDir() {
+			file = filepath.Join(file, "submission.py")
+		} else {
+			name, ok := strings.CutSuffix(id, ".py")
+			if !ok {
+				continue
+			}
+			id = name
+		}
+
+		code, err := os.ReadFile(file)
+		if os.IsNotExist(err) && e.IsDir() {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := &Player{ID: id, Code: string(code), Rating: initial}
+		if table != nil {
+			if r, ok := table.Ratings[id]; ok {
+				p.Rating = r.Rating
+			}
+		}
+		players = append(players, p)
+	}
+
+	return players, nil
+}
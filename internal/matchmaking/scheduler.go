@@ -0,0 +1,158 @@
+// Package matchmaking picks the pairings the runner plays among the active submissions
+package matchmaking
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Player is an active submission
+type Player struct {
+	ID     string
+	Code   string
+	Rating float64
+}
+
+// Pairing is a match to schedule, in seat order
+type Pairing struct {
+	P1      *Player
+	P2      *Player
+	Explore bool // the opponent was picked at random rather than by rating
+}
+
+// Scheduler pairs players of similar rating, sometimes anyone at random so
+// ratings keep getting tested across the field, and limits how many matches
+// each player gets within a time window. What it booked is kept in memory only.
+type Scheduler struct {
+	Exploration float64       // share of pairings with a random opponent, 0 to 1
+	MaxMatches  int           // per player within Window, 0 for no limit
+	Window      time.Duration // sliding window of MaxMatches
+	rng         *rand.Rand
+	played      map[string][]time.Time // booked match times per player
+	lastOpp     map[string]string      // latest opponent per player
+}
+
+func NewScheduler(exploration float64, maxMatches int, window time.Duration, seed int64) *Scheduler {
+	return &Scheduler{
+		Exploration: exploration,
+		MaxMatches:  maxMatches,
+		Window:      window,
+		rng:         rand.New(rand.NewSource(seed)),
+		played:      make(map[string][]time.Time),
+		lastOpp:     make(map[string]string),
+	}
+}
+
+// Pick pairs up to n matches among players at now. Every player is in at
+// most one of them and no one is paired with their latest opponent again
+// while someone else is available. Nothing is booked until Book is called
+// with a pairing, so one that never reaches the queue costs nobody a match.
+func (s *Scheduler) Pick(players []*Player, n int, now time.Time) []Pairing {
+	eligible := make([]*Player, 0, len(players))
+	for _, p := range players {
+		if s.underLimit(p.ID, now) {
+			eligible = append(eligible, p)
+		}
+	}
+	s.rng.Shuffle(len(eligible), func(i, j int) {
+		eligible[i], eligible[j] = eligible[j], eligible[i]
+	})
+
+	paired := make(map[string]bool)
+	pairings := make([]Pairing, 0, n)
+
+	for _, p := range eligible {
+		if len(pairings) >= n {
+			break
+		}
+		if paired[p.ID] {
+			continue
+		}
+
+		candidates := make([]*Player, 0, len(eligible))
+		for _, o := range eligible {
+			if o.ID != p.ID && !paired[o.ID] {
+				candidates = append(candidates, o)
+			}
+		}
+		if len(candidates) > 1 {
+			candidates = s.withoutLastOpponent(p, candidates)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		explore := s.rng.Float64() < s.Exploration
+		var opp *Player
+		if explore {
+			opp = candidates[s.rng.Intn(len(candidates))]
+		} else {
+			opp = closest(p, candidates)
+		}
+
+		pairing := Pairing{P1: p, P2: opp, Explore: explore}
+		if s.rng.Intn(2) == 1 {
+			pairing.P1, pairing.P2 = opp, p
+		}
+		pairings = append(pairings, pairing)
+
+		paired[p.ID], paired[opp.ID] = true, true
+	}
+
+	return pairings
+}
+
+func (s *Scheduler) underLimit(playerID string, now time.Time) bool {
+	if s.MaxMatches <= 0 {
+		return true
+	}
+
+	// forget what left the window
+	times := s.played[playerID]
+	kept := times[:0]
+	for _, t := range times {
+		if now.Sub(t) < s.Window {
+			kept = append(kept, t)
+		}
+	}
+	s.played[playerID] = kept
+
+	return len(kept) < s.MaxMatches
+}
+
+// Book counts p against both players' limits and makes each the other's
+// latest opponent, once the match has been queued
+func (s *Scheduler) Book(p Pairing, now time.Time) {
+	a, b := p.P1.ID, p.P2.ID
+	s.played[a] = append(s.played[a], now)
+	s.played[b] = append(s.played[b], now)
+	s.lastOpp[a] = b
+	s.lastOpp[b] = a
+}
+
+func (s *Scheduler) withoutLastOpponent(p *Player, candidates []*Player) []*Player {
+	last, ok := s.lastOpp[p.ID]
+	if !ok {
+		return candidates
+	}
+
+	kept := make([]*Player, 0, len(candidates))
+	for _, o := range candidates {
+		if o.ID != last {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// first of the candidates closest in rating, candidates are in random order
+func closest(p *Player, candidates []*Player) *Player {
+	best := candidates[0]
+	for _, o := range candidates[1:] {
+		if math.Abs(o.Rating-p.Rating) < math.Abs(best.Rating-p.Rating) {
+			best = o
+		}
+	}
+	return best
+}
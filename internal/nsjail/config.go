@@ -88,9 +88,26 @@ func WriteConfig(c *config.Config, cg cgroup.Cgroup) (*proto_nsjail.NsJailConfig
 		return nil, err
 	}
 
+	if c.WarmPool {
+		if err := writeWarm(c, msg); err != nil {
+			return nil, err
+		}
+	}
+
 	return msg, nil
 }
 
+// warm jails idle in the pool before their match starts, so the wall time
+// limit is enforced by the match context instead of nsjail
+func writeWarm(c *config.Config, msg *proto_nsjail.NsJailConfig) error {
+	warm := proto.Clone(msg).(*proto_nsjail.NsJailConfig)
+
+	warm.TimeLimit = proto.Uint32(0)
+	warm.Envar = append(warm.Envar, "OCEANMASTER_WARM=1")
+
+	return write(c.NsjailWarmCfgPath, warm)
+}
+
 func write(path string, msg *proto_nsjail.NsJailConfig) error {
 	content, err := prototext.Marshal(msg)
 	if err != nil {
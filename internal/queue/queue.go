@@ -1,7 +1,11 @@
 package queue
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/delta/code-runner/internal/config"
 	"github.com/rabbitmq/amqp091-go"
@@ -11,6 +15,9 @@ type MatchJobQueue struct {
 	cfg  config.MatchJobQueueConfig
 	conn *amqp091.Connection
 	ch   *amqp091.Channel
+
+	consumerTag string
+	consumerSeq int
 }
 
 // broker side back pressure
@@ -19,9 +26,12 @@ func (q *MatchJobQueue) SetMaxConcurrentMatches(max int) error {
 }
 
 func (q *MatchJobQueue) Consume() (<-chan amqp091.Delivery, error) {
+	q.consumerSeq++
+	tag := fmt.Sprintf("runner-%d-%d", os.Getpid(), q.consumerSeq)
+
 	msgs, err := q.ch.Consume(
 		q.cfg.QueueName,
-		"",
+		tag,
 		false, // no manual ack
 		false,
 		false,
@@ -32,9 +42,58 @@ func (q *MatchJobQueue) Consume() (<-chan amqp091.Delivery, error) {
 		return nil, err
 	}
 
+	q.consumerTag = tag
+
 	return msgs, nil
 }
 
+// Resubscribe applies a new prefetch limit by starting a fresh consumer.
+// Qos only affects consumers created after it, so the old consumer is
+// cancelled once handoff has taken the new delivery channel. The old channel
+// is closed after its buffered deliveries are drained, and those deliveries
+// can still be acked on this channel.
+func (q *MatchJobQueue) Resubscribe(max int, handoff func(<-chan amqp091.Delivery)) error {
+	if err := q.SetMaxConcurrentMatches(max); err != nil {
+		return err
+	}
+
+	oldTag := q.consumerTag
+
+	msgs, err := q.Consume()
+	if err != nil {
+		return err
+	}
+
+	handoff(msgs)
+
+	if err := q.ch.Cancel(oldTag, false); err != nil {
+		return fmt.Errorf("cancel consumer: %w", err)
+	}
+
+	return nil
+}
+
+// Publish sends a job to the exchange the queue is bound to, as persistent JSON
+func (q *MatchJobQueue) Publish(ctx context.Context, job any) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode job: %w", err)
+	}
+
+	return q.ch.PublishWithContext(
+		ctx,
+		q.cfg.ExchangeName,
+		q.cfg.RoutingKey,
+		false,
+		false,
+		amqp091.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp091.Persistent,
+			Body:         body,
+		},
+	)
+}
+
 func NewMatchJobQueue(cfg config.MatchJobQueueConfig) (*MatchJobQueue, error) {
 	conn, err := amqp091.Dial(cfg.URL)
 	if err != nil {
@@ -0,0 +1,34 @@
+package ratings
+
+import "math"
+
+// Elo moves both ratings by K times how much the result beat the expectation
+type Elo struct {
+	K       float64
+	Initial float64
+}
+
+func DefaultElo() *Elo {
+	return &Elo{K: 32, Initial: 1500}
+}
+
+func (e *Elo) Name() string {
+	return "elo"
+}
+
+func (e *Elo) NewRating(player string) *Rating {
+	return &Rating{Player: player, Rating: e.Initial}
+}
+
+// one game at a time, so the order matters
+func (e *Elo) Rate(ratings map[string]*Rating, games []Game) {
+	for _, g := range games {
+		p1, p2 := ratings[g.P1], ratings[g.P2]
+
+		expected := 1 / (1 + math.Pow(10, (p2.Rating-p1.Rating)/400))
+		delta := e.K * (g.Score - expected)
+
+		p1.Rating += delta
+		p2.Rating -= delta
+	}
+}
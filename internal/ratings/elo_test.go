@@ -0,0 +1,107 @@
+package ratings
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/delta/code-runner/internal/engine"
+)
+
+func TestEloWinAndDraw(t *testing.T) {
+	tests := []struct {
+		name   string
+		r1, r2 float64
+		score  float64
+		want1  float64
+	}{
+		{"win between equals", 1500, 1500, 1, 1516},
+		{"loss between equals", 1500, 1500, 0, 1484},
+		{"draw between equals", 1500, 1500, 0.5, 1500},
+		// a 400 point gap expects 10/11 for the stronger side
+		{"draw gains the weaker side", 1500, 1900, 0.5, 1500 + 32*(0.5-1.0/11)},
+		{"draw costs the stronger side", 1900, 1500, 0.5, 1900 - 32*(0.5-1.0/11)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := DefaultElo()
+			ratings := map[string]*Rating{
+				"a": {Player: "a", Rating: tt.r1},
+				"b": {Player: "b", Rating: tt.r2},
+			}
+			e.Rate(ratings, []Game{{ID: "1", P1: "a", P2: "b", Score: tt.score}})
+
+			if !near(ratings["a"].Rating, tt.want1, 1e-9) {
+				t.Errorf("a: got %.4f, want %.4f", ratings["a"].Rating, tt.want1)
+			}
+			// rating is only moved between the two
+			if sum := ratings["a"].Rating + ratings["b"].Rating; !near(sum, tt.r1+tt.r2, 1e-9) {
+				t.Errorf("ratings sum to %.4f, want %.4f", sum, tt.r1+tt.r2)
+			}
+		})
+	}
+}
+
+func TestEloForfeits(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec := func(id string, failed int, stage string, err error) engine.MatchRecord {
+		r := engine.MatchRecord{ID: id, P1: "a", P2: "b", Failed: failed, Stage: stage, Finished: at}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		rec      engine.MatchRecord
+		counted  bool
+		rate     bool    // -rate-forfeits
+		want     float64 // a's rating
+		forfeits [2]int
+	}{
+		{"turn forfeit rated", rec("1", engine.PlayerTwo, "turn", errors.New("p2 turn: EOF")), true, true, 1516, [2]int{0, 1}},
+		{"handshake forfeit rated", rec("1", engine.PlayerOne, "handshake", errors.New("p1 handshake: EOF")), true, true, 1484, [2]int{1, 0}},
+		{"forfeit only counted", rec("1", engine.PlayerTwo, "turn", errors.New("p2 turn: EOF")), true, false, 1500, [2]int{0, 1}},
+		{"sandbox failure is the runner's", rec("1", engine.PlayerTwo, "sandbox", errors.New("p2 sandbox: create")), false, true, 1500, [2]int{}},
+		{"failure without a stage", rec("1", engine.PlayerTwo, "", errors.New("p2 turn: EOF")), false, true, 1500, [2]int{}},
+		{"wall timeout counts for nobody", rec("1", -1, "", engine.ErrWallTimeout), false, true, 1500, [2]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, ok := FromRecord(tt.rec)
+			if ok != tt.counted {
+				t.Fatalf("counted %v, want %v", ok, tt.counted)
+			}
+
+			e := DefaultElo()
+			table := NewTable(e, tt.rate)
+			var games []Game
+			if ok {
+				games = append(games, g)
+			}
+			if _, err := table.Apply(e, games); err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				if len(table.Ratings) != 0 {
+					t.Errorf("uncounted game rated %v", table.Ratings)
+				}
+				return
+			}
+
+			a, b := table.Ratings["a"], table.Ratings["b"]
+			if !near(a.Rating, tt.want, 1e-9) {
+				t.Errorf("a: got %.4f, want %.4f", a.Rating, tt.want)
+			}
+			if got := [2]int{a.Forfeits, b.Forfeits}; got != tt.forfeits {
+				t.Errorf("forfeits %v, want %v", got, tt.forfeits)
+			}
+			if a.Wins+a.Losses != 1 || b.Wins+b.Losses != 1 {
+				t.Errorf("a %d-%d, b %d-%d, want one win and one loss", a.Wins, a.Losses, b.Wins, b.Losses)
+			}
+		})
+	}
+}
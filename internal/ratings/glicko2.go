@@ -0,0 +1,148 @@
+package ratings
+
+import (
+	"math"
+	"time"
+)
+
+// glicko-2 works on this scale internally
+const glickoScale = 173.7178
+
+// Glicko2 rates games in rating periods, everyone in a period is rated against
+// their opponents' ratings from before it. The deviation says how sure the
+// rating is: it shrinks with games and grows by the volatility in every period
+// a player sits out.
+//
+// Games are grouped into periods of Period by their time. Games rated in a
+// later run start a new period even when they fall into an earlier one.
+type Glicko2 struct {
+	Tau               float64 // limits volatility changes, 0.3 to 1.2
+	Initial           float64
+	InitialDeviation  float64
+	InitialVolatility float64
+	Period            time.Duration
+}
+
+func DefaultGlicko2() *Glicko2 {
+	return &Glicko2{
+		Tau:               0.5,
+		Initial:           1500,
+		InitialDeviation:  350,
+		InitialVolatility: 0.06,
+		Period:            24 * time.Hour,
+	}
+}
+
+func (g *Glicko2) Name() string {
+	return "glicko2"
+}
+
+func (g *Glicko2) NewRating(player string) *Rating {
+	return &Rating{
+		Player:     player,
+		Rating:     g.Initial,
+		Deviation:  g.InitialDeviation,
+		Volatility: g.InitialVolatility,
+	}
+}
+
+type glickoResult struct {
+	mu    float64
+	phi   float64
+	score float64
+}
+
+func (g *Glicko2) Rate(ratings map[string]*Rating, games []Game) {
+	for start := 0; start < len(games); {
+		end := start + 1
+		if g.Period > 0 {
+			period := games[start].Time.Truncate(g.Period)
+			for end < len(games) && games[end].Time.Truncate(g.Period).Equal(period) {
+				end++
+			}
+		}
+		g.ratePeriod(ratings, games[start:end])
+		start = end
+	}
+}
+
+func (g *Glicko2) ratePeriod(ratings map[string]*Rating, games []Game) {
+	results := make(map[string][]glickoResult)
+	for _, game := range games {
+		p1, p2 := ratings[game.P1], ratings[game.P2]
+		results[game.P1] = append(results[game.P1], glickoResult{toMu(p2.Rating), p2.Deviation / glickoScale, game.Score})
+		results[game.P2] = append(results[game.P2], glickoResult{toMu(p1.Rating), p1.Deviation / glickoScale, 1 - game.Score})
+	}
+
+	for _, player := range sortedKeys(ratings) {
+		r := ratings[player]
+		if rs, ok := results[player]; ok {
+			g.update(r, rs)
+			continue
+		}
+		phi := r.Deviation / glickoScale
+		r.Deviation = math.Min(math.Sqrt(phi*phi+r.Volatility*r.Volatility)*glickoScale, g.InitialDeviation)
+	}
+}
+
+// update follows the steps of Glickman's "Example of the Glicko-2 system"
+func (g *Glicko2) update(r *Rating, results []glickoResult) {
+	mu := toMu(r.Rating)
+	phi := r.Deviation / glickoScale
+	sigma := r.Volatility
+
+	var vInv, improvement float64
+	for _, res := range results {
+		gPhi := 1 / math.Sqrt(1+3*res.phi*res.phi/(math.Pi*math.Pi))
+		expected := 1 / (1 + math.Exp(-gPhi*(mu-res.mu)))
+		vInv += gPhi * gPhi * expected * (1 - expected)
+		improvement += gPhi * (res.score - expected)
+	}
+	v := 1 / vInv
+	delta := v * improvement
+
+	// new volatility by the Illinois algorithm
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(g.Tau*g.Tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*g.Tau) < 0 {
+			k++
+		}
+		B = a - k*g.Tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > 1e-6 {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	newSigma := math.Exp(A / 2)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	r.Rating = newMu*glickoScale + 1500
+	r.Deviation = newPhi * glickoScale
+	r.Volatility = newSigma
+}
+
+func toMu(rating float64) float64 {
+	return (rating - 1500) / glickoScale
+}
@@ -0,0 +1,82 @@
+package ratings
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+// the worked example of Glickman's "Example of the Glicko-2 system"
+func TestGlicko2Example(t *testing.T) {
+	g := DefaultGlicko2()
+	r := &Rating{Player: "p", Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	g.update(r, []glickoResult{
+		{toMu(1400), 30 / glickoScale, 1},
+		{toMu(1550), 100 / glickoScale, 0},
+		{toMu(1700), 300 / glickoScale, 0},
+	})
+
+	if !near(r.Rating, 1464.05, 0.01) || !near(r.Deviation, 151.52, 0.01) || !near(r.Volatility, 0.06, 1e-4) {
+		t.Errorf("got %.2f/%.2f/%.5f, want 1464.05/151.52/0.06000", r.Rating, r.Deviation, r.Volatility)
+	}
+}
+
+// the same example through Rate: one period, opponents rated from before it
+func TestGlicko2Period(t *testing.T) {
+	g := DefaultGlicko2()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ratings := map[string]*Rating{
+		"p":    {Player: "p", Rating: 1500, Deviation: 200, Volatility: 0.06},
+		"a":    {Player: "a", Rating: 1400, Deviation: 30, Volatility: 0.06},
+		"b":    {Player: "b", Rating: 1550, Deviation: 100, Volatility: 0.06},
+		"c":    {Player: "c", Rating: 1700, Deviation: 300, Volatility: 0.06},
+		"idle": {Player: "idle", Rating: 1500, Deviation: 200, Volatility: 0.06},
+	}
+	g.Rate(ratings, []Game{
+		{ID: "1", P1: "p", P2: "a", Score: 1, Time: at},
+		{ID: "2", P1: "b", P2: "p", Score: 1, Time: at.Add(time.Hour)},
+		{ID: "3", P1: "p", P2: "c", Score: 0, Time: at.Add(2 * time.Hour)},
+	})
+
+	p := ratings["p"]
+	if !near(p.Rating, 1464.05, 0.01) || !near(p.Deviation, 151.52, 0.01) {
+		t.Errorf("p: got %.2f/%.2f, want 1464.05/151.52", p.Rating, p.Deviation)
+	}
+
+	// sitting the period out only grows the deviation
+	idle := ratings["idle"]
+	wantRD := math.Sqrt(200*200 + 0.06*0.06*glickoScale*glickoScale)
+	if idle.Rating != 1500 || !near(idle.Deviation, wantRD, 1e-9) {
+		t.Errorf("idle: got %.2f/%.4f, want 1500/%.4f", idle.Rating, idle.Deviation, wantRD)
+	}
+}
+
+// games in separate periods are rated against the updated ratings
+func TestGlicko2Periods(t *testing.T) {
+	g := DefaultGlicko2()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	games := []Game{
+		{ID: "1", P1: "a", P2: "b", Score: 1, Time: at},
+		{ID: "2", P1: "a", P2: "b", Score: 1, Time: at.Add(g.Period)},
+	}
+
+	split := map[string]*Rating{"a": g.NewRating("a"), "b": g.NewRating("b")}
+	g.Rate(split, games)
+
+	g.Period = 7 * 24 * time.Hour
+	one := map[string]*Rating{"a": g.NewRating("a"), "b": g.NewRating("b")}
+	g.Rate(one, games)
+
+	if near(split["a"].Rating, one["a"].Rating, 1e-6) {
+		t.Errorf("two periods rated like one: %.2f", split["a"].Rating)
+	}
+	if !(split["a"].Rating > 1500 && split["b"].Rating < 1500) {
+		t.Errorf("winner %.2f, loser %.2f", split["a"].Rating, split["b"].Rating)
+	}
+}
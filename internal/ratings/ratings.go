@@ -0,0 +1,213 @@
+// Package ratings ranks players by Elo or Glicko-2 from the records of their
+// matches, so beating strong players counts for more than beating weak ones.
+package ratings
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// Game is one rated result. Score is P1's: 1 for a win, 0.5 for a draw, 0 for a loss.
+type Game struct {
+	ID      string
+	P1      string
+	P2      string
+	Score   float64
+	Forfeit bool // the loser's sandbox failed
+	Time    time.Time
+}
+
+// FromRecord turns a match record into a game. A match that failed for
+// neither side, or was played against itself, counts for nobody. So does a
+// sandbox that did not start, which is the runner's failure rather than the
+// submission's, and a failure recorded without its stage.
+func FromRecord(rec engine.MatchRecord) (Game, bool) {
+	g := Game{ID: rec.ID, P1: rec.P1, P2: rec.P2, Time: rec.Finished}
+	if rec.P1 == rec.P2 {
+		return g, false
+	}
+
+	switch {
+	case rec.Failed != -1 && !rec.Forfeited():
+		return g, false
+	case rec.Failed == engine.PlayerOne:
+		g.Score, g.Forfeit = 0, true
+	case rec.Failed == engine.PlayerTwo:
+		g.Score, g.Forfeit = 1, true
+	case rec.Error != "" || rec.Result == nil:
+		return g, false
+	case rec.Result.Winner == engine.PlayerOne:
+		g.Score = 1
+	case rec.Result.Winner == engine.PlayerTwo:
+		g.Score = 0
+	case rec.Result.Winner == engine.Draw:
+		g.Score = 0.5
+	default:
+		return g, false
+	}
+
+	return g, true
+}
+
+type Rating struct {
+	Player     string  `json:"player"`
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation,omitempty"`  // Glicko-2 only
+	Volatility float64 `json:"volatility,omitempty"` // Glicko-2 only
+	Games      int     `json:"games"`
+	Wins       int     `json:"wins"`
+	Draws      int     `json:"draws"`
+	Losses     int     `json:"losses"`
+	Forfeits   int     `json:"forfeits"` // losses by the player's own failure, also in Losses
+}
+
+// System updates ratings from games given in play order
+type System interface {
+	Name() string
+	NewRating(player string) *Rating
+	Rate(ratings map[string]*Rating, games []Game)
+}
+
+func NewSystem(name string) (System, error) {
+	switch name {
+	case "elo":
+		return DefaultElo(), nil
+	case "glicko2":
+		return DefaultGlicko2(), nil
+	}
+	return nil, fmt.Errorf("unknown rating system %q, want elo or glicko2", name)
+}
+
+// Table is the state kept between runs, so new results are rated on top of the old ones
+type Table struct {
+	System       string             `json:"system"`
+	RateForfeits bool               `json:"rate_forfeits"` // otherwise forfeits are only counted
+	Ratings      map[string]*Rating `json:"ratings"`
+	Applied      map[string]bool    `json:"applied"` // IDs of the games already rated
+}
+
+func NewTable(sys System, rateForfeits bool) *Table {
+	return &Table{
+		System:       sys.Name(),
+		RateForfeits: rateForfeits,
+		Ratings:      make(map[string]*Rating),
+		Applied:      make(map[string]bool),
+	}
+}
+
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if t.Ratings == nil {
+		t.Ratings = make(map[string]*Rating)
+	}
+	if t.Applied == nil {
+		t.Applied = make(map[string]bool)
+	}
+
+	return &t, nil
+}
+
+func (t *Table) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Apply rates the games that were not applied yet, oldest first, and returns
+// how many it took
+func (t *Table) Apply(sys System, games []Game) (int, error) {
+	if sys.Name() != t.System {
+		return 0, fmt.Errorf("table is rated with %s, not %s", t.System, sys.Name())
+	}
+
+	fresh := make([]Game, 0, len(games))
+	for _, g := range games {
+		if t.Applied[g.ID] {
+			continue
+		}
+		t.Applied[g.ID] = true
+		fresh = append(fresh, g)
+	}
+
+	slices.SortStableFunc(fresh, func(a, b Game) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	rated := make([]Game, 0, len(fresh))
+	for _, g := range fresh {
+		p1, p2 := t.rating(sys, g.P1), t.rating(sys, g.P2)
+		p1.Games++
+		p2.Games++
+
+		switch g.Score {
+		case 1:
+			p1.Wins++
+			p2.Losses++
+			if g.Forfeit {
+				p2.Forfeits++
+			}
+		case 0:
+			p2.Wins++
+			p1.Losses++
+			if g.Forfeit {
+				p1.Forfeits++
+			}
+		default:
+			p1.Draws++
+			p2.Draws++
+		}
+
+		if !g.Forfeit || t.RateForfeits {
+			rated = append(rated, g)
+		}
+	}
+
+	sys.Rate(t.Ratings, rated)
+
+	return len(fresh), nil
+}
+
+func (t *Table) rating(sys System, player string) *Rating {
+	r, ok := t.Ratings[player]
+	if !ok {
+		r = sys.NewRating(player)
+		t.Ratings[player] = r
+	}
+	return r
+}
+
+// Ranked returns the ratings, best first
+func (t *Table) Ranked() []*Rating {
+	ranked := make([]*Rating, 0, len(t.Ratings))
+	for _, r := range t.Ratings {
+		ranked = append(ranked, r)
+	}
+
+	slices.SortFunc(ranked, func(a, b *Rating) int {
+		if c := cmp.Compare(b.Rating, a.Rating); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Player, b.Player)
+	})
+
+	return ranked
+}
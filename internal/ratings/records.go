@@ -0,0 +1,101 @@
+package ratings
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// ReadRecords reads match records from a result.json the runner writes next to
+// a match log, a results.json of cmd/tournament, or a directory holding any
+// number of those
+func ReadRecords(path string) ([]engine.MatchRecord, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readRecordFile(path)
+	}
+
+	recs := make([]engine.MatchRecord, 0)
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (d.Name() != "result.json" && d.Name() != "results.json") {
+			return nil
+		}
+		fileRecs, err := readRecordFile(p)
+		if err != nil {
+			return err
+		}
+		recs = append(recs, fileRecs...)
+		return nil
+	})
+
+	return recs, err
+}
+
+func readRecordFile(path string) ([]engine.MatchRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// a tournament's games, or a single record
+	var file struct {
+		Games []engine.MatchRecord `json:"games"`
+		engine.MatchRecord
+	}
+	file.Failed = -1
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if file.Games != nil {
+		return file.Games, nil
+	}
+	if file.ID == "" {
+		return nil, fmt.Errorf("%s: not a match record", path)
+	}
+	return []engine.MatchRecord{file.MatchRecord}, nil
+}
+
+// ReadRecordStream reads one JSON match record per line, as a results queue
+// consumer would hand them on
+func ReadRecordStream(r io.Reader) ([]engine.MatchRecord, error) {
+	recs := make([]engine.MatchRecord, 0)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		rec := engine.MatchRecord{Failed: -1}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, sc.Err()
+}
+
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
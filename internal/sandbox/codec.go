@@ -0,0 +1,136 @@
+package sandbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Encoding string
+
+const (
+	// one JSON document per line
+	EncodingJSON Encoding = "json"
+	// 4 byte big endian length followed by a MessagePack document
+	EncodingMsgpack Encoding = "msgpack"
+)
+
+// keeps a bad length prefix from allocating the whole jail memory
+const maxFrameSize = 64 * 1024 * 1024
+
+func (e Encoding) Valid() bool {
+	return e == EncodingJSON || e == EncodingMsgpack
+}
+
+func encodeFrame(enc Encoding, v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if enc != EncodingMsgpack {
+		return append(b, '\n'), nil
+	}
+
+	// go through JSON so both encodings carry the exact same document
+	// (string map keys, json field names, omitempty)
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var tree any
+	if err := d.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	body, err := msgpack.Marshal(normalizeNumbers(tree))
+	if err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+
+	return append(frame, body...), nil
+}
+
+func decodeFrame(enc Encoding, frame []byte, v any) error {
+	if enc != EncodingMsgpack {
+		return json.Unmarshal(frame, v)
+	}
+
+	var tree any
+	if err := msgpack.Unmarshal(frame, &tree); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// json.Number would be encoded as a msgpack string
+func normalizeNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, e := range t {
+			t[k] = normalizeNumbers(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = normalizeNumbers(e)
+		}
+	}
+	return v
+}
+
+func readFrame(ctx context.Context, r *bufio.Reader) ([]byte, error) {
+	type result struct {
+		frame []byte
+		err   error
+	}
+
+	ch := make(chan result, 1)
+
+	go func() {
+		var hdr [4]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			ch <- result{nil, err}
+			return
+		}
+
+		n := binary.BigEndian.Uint32(hdr[:])
+		if n > maxFrameSize {
+			ch <- result{nil, fmt.Errorf("frame of %d bytes exceeds limit", n)}
+			return
+		}
+
+		frame := make([]byte, n)
+		_, err := io.ReadFull(r, frame)
+		ch <- result{frame, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return res.frame, nil
+	}
+}
@@ -0,0 +1,18 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// NewDevSandbox runs the wrapper with a local python, outside of nsjail, for
+// local tools such as cmd/play. The submission runs with the caller's
+// privileges and without limits, never use it for untrusted code.
+func NewDevSandbox(ctx context.Context, pythonPath, wrapperPath, submissionDir string) (*Sandbox, error) {
+	cmd := exec.CommandContext(ctx, pythonPath, wrapperPath)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("PYTHONPATH=%s", submissionDir))
+
+	return newSandbox(cmd)
+}
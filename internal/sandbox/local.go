@@ -0,0 +1,73 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/delta/code-runner/internal/cgroup"
+	"github.com/delta/code-runner/internal/config"
+	"github.com/delta/code-runner/internal/nsjail"
+)
+
+// Local returns how local tools such as cmd/play start the sandbox of a
+// submission directory: in nsjail with the runner config when jail is set,
+// which needs root and the runner image, or else with NewDevSandbox.
+func Local(cfg *config.Config, jail bool, pythonPath, wrapperPath string) (func(ctx context.Context, dir string) (*Sandbox, error), error) {
+	if jail {
+		cg, err := cgroup.UnshareAndMount()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := nsjail.WriteConfig(cfg, cg); err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context, dir string) (*Sandbox, error) {
+			return NewSandbox(ctx, cfg.NsjailPath, cfg.NsjailCfgPath, dir, cfg.JailSubmissionPath)
+		}, nil
+	}
+
+	// submissions run from their own directory
+	wrapperPath, err := filepath.Abs(wrapperPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, dir string) (*Sandbox, error) {
+		return NewDevSandbox(ctx, pythonPath, wrapperPath, dir)
+	}, nil
+}
+
+// SubmissionDir returns a directory with the submission at path as
+// submission.py: path itself when it is a directory, or else a temporary copy
+// that cleanup removes
+func SubmissionDir(path string) (dir string, cleanup func(), err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if info.IsDir() {
+		abs, err := filepath.Abs(path)
+		return abs, func() {}, err
+	}
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err = os.MkdirTemp("", "submission-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	if err := os.WriteFile(filepath.Join(dir, "submission.py"), code, 0644); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return dir, cleanup, nil
+}
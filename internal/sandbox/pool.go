@@ -0,0 +1,243 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wrapper.py prints this once the SDK is imported and it waits for a load command
+const WARM_MSG = "__WARM_V1__"
+
+const (
+	warmReadyTimeout = 30 * time.Second
+	warmRetryDelay   = 5 * time.Second
+)
+
+var ErrPoolEmpty = errors.New("no warm sandbox available")
+
+type PoolConfig struct {
+	NsjailPath         string
+	NsjailWarmCfgPath  string
+	SlotRoot           string
+	JailSubmissionPath string
+	Size               int
+}
+
+// loadCmd tells a warm wrapper which module to import as the submission
+type loadCmd struct {
+	Load string `json:"load"`
+}
+
+type warmSandbox struct {
+	s   *Sandbox
+	dir string
+}
+
+// Pool keeps pre-started jails that have imported the SDK and wait for a
+// submission. Every sandbox is handed out at most once; the caller owns it
+// after Acquire and the pool starts a replacement in the background.
+type Pool struct {
+	mu       sync.Mutex
+	cfg      PoolConfig
+	gen      int
+	idle     []*warmSandbox
+	starting int
+	nextSlot int
+	closed   bool
+}
+
+func NewPool(cfg PoolConfig) *Pool {
+	p := &Pool{cfg: cfg}
+
+	p.mu.Lock()
+	p.fill()
+	p.mu.Unlock()
+
+	return p
+}
+
+// Reconfigure discards idle sandboxes started with the previous config and
+// refills the pool. Sandboxes already handed out are not affected.
+func (p *Pool) Reconfigure(cfg PoolConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cfg = cfg
+	p.gen++
+
+	for _, w := range p.idle {
+		go w.s.Destroy()
+	}
+	p.idle = nil
+
+	p.fill()
+}
+
+// Acquire hands out a warm sandbox with the files of submissionDir loaded.
+// The sandbox is killed when ctx ends. It returns ErrPoolEmpty if no warm
+// sandbox is ready, in which case the caller should cold start one.
+func (p *Pool) Acquire(ctx context.Context, submissionDir string) (*Sandbox, error) {
+	p.mu.Lock()
+	if len(p.idle) == 0 {
+		p.mu.Unlock()
+		return nil, ErrPoolEmpty
+	}
+	w := p.idle[0]
+	p.idle = p.idle[1:]
+	p.fill()
+	p.mu.Unlock()
+
+	if err := copyDir(submissionDir, w.dir); err != nil {
+		w.s.Destroy()
+		return nil, fmt.Errorf("copy submission: %w", err)
+	}
+
+	if err := w.s.Send(loadCmd{Load: "submission"}); err != nil {
+		w.s.Destroy()
+		return nil, fmt.Errorf("send load: %w", err)
+	}
+
+	w.s.Bind(ctx)
+
+	return w.s, nil
+}
+
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, w := range p.idle {
+		go w.s.Destroy()
+	}
+	p.idle = nil
+}
+
+// must hold p.mu
+func (p *Pool) fill() {
+	if p.closed {
+		return
+	}
+
+	for len(p.idle)+p.starting < p.cfg.Size {
+		p.starting++
+		p.nextSlot++
+		go p.spawn(p.cfg, p.gen, p.nextSlot)
+	}
+}
+
+func (p *Pool) spawn(cfg PoolConfig, gen, slot int) {
+	w, err := startWarm(cfg, slot)
+
+	if err != nil {
+		log.Println("WARM SANDBOX FAILED:", err)
+		time.Sleep(warmRetryDelay)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.starting--
+
+	if w != nil {
+		if gen != p.gen || p.closed {
+			go w.s.Destroy()
+		} else {
+			p.idle = append(p.idle, w)
+		}
+	}
+
+	p.fill()
+}
+
+func startWarm(cfg PoolConfig, slot int) (*warmSandbox, error) {
+	dir := path.Join(cfg.SlotRoot, fmt.Sprint(slot))
+
+	// slot numbers restart with the process, drop leftovers of a previous run
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("clean slot: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("mkdir slot: %w", err)
+	}
+
+	s, err := NewWarmSandbox(cfg.NsjailPath, cfg.NsjailWarmCfgPath, dir, cfg.JailSubmissionPath)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	s.cleanup = func() { os.RemoveAll(dir) }
+
+	if err := s.Start(); err != nil {
+		s.Destroy()
+		return nil, fmt.Errorf("start: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), warmReadyTimeout)
+	defer cancel()
+
+	data := ""
+	if err := s.RecvOutput(ctx, &data); err != nil {
+		s.Destroy()
+		return nil, fmt.Errorf("wait warm: %w", err)
+	}
+
+	if strings.TrimSpace(data) != WARM_MSG {
+		s.Destroy()
+		return nil, fmt.Errorf("invalid warm message %q", data)
+	}
+
+	return &warmSandbox{s: s, dir: dir}, nil
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		// a symlink would pull host files into the jail, so only regular
+		// files and directories are copied
+		info, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0700)
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("%s is not a regular file or directory", rel)
+		}
+
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
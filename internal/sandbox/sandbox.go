@@ -3,10 +3,10 @@ package sandbox
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os/exec"
+	"sync"
 )
 
 type Sandbox struct {
@@ -17,6 +17,14 @@ type Sandbox struct {
 
 	outR *bufio.Reader
 	errR *bufio.Reader
+
+	// negotiated at handshake, applies to Send and RecvOutput
+	enc Encoding
+
+	// closed by Destroy, stops a Bind watcher
+	done        chan struct{}
+	destroyOnce sync.Once
+	cleanup     func()
 }
 
 func NewSandbox(ctx context.Context, nsjailPath, nsjailCfgPath, submissionDir, jailSubmissionDir string) (*Sandbox, error) {
@@ -27,6 +35,22 @@ func NewSandbox(ctx context.Context, nsjailPath, nsjailCfgPath, submissionDir, j
 		"--bindmount_ro", fmt.Sprintf("%s:%s", submissionDir, jailSubmissionDir),
 	)
 
+	return newSandbox(cmd)
+}
+
+// NewWarmSandbox creates a sandbox that is not tied to any match yet.
+// It must be attached to a match context with Bind once it is handed out.
+func NewWarmSandbox(nsjailPath, nsjailWarmCfgPath, slotDir, jailSubmissionDir string) (*Sandbox, error) {
+	cmd := exec.Command(
+		nsjailPath,
+		"-C", nsjailWarmCfgPath,
+		"--bindmount_ro", fmt.Sprintf("%s:%s", slotDir, jailSubmissionDir),
+	)
+
+	return newSandbox(cmd)
+}
+
+func newSandbox(cmd *exec.Cmd) (*Sandbox, error) {
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, err
@@ -47,6 +71,8 @@ func NewSandbox(ctx context.Context, nsjailPath, nsjailCfgPath, submissionDir, j
 		stderr: stderr,
 		outR:   bufio.NewReader(stdout),
 		errR:   bufio.NewReader(stderr),
+		enc:    EncodingJSON,
+		done:   make(chan struct{}),
 	}
 
 	return s, nil
@@ -56,12 +82,29 @@ func (s *Sandbox) Start() error {
 	return s.cmd.Start()
 }
 
+// Bind kills the sandbox process when ctx ends, like exec.CommandContext
+// does for sandboxes created with NewSandbox.
+func (s *Sandbox) Bind(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			if s.cmd.Process != nil {
+				_ = s.cmd.Process.Kill()
+			}
+		case <-s.done:
+		}
+	}()
+}
+
+func (s *Sandbox) SetEncoding(enc Encoding) {
+	s.enc = enc
+}
+
 func (s *Sandbox) Send(inp any) error {
-	b, err := json.Marshal(inp)
+	b, err := encodeFrame(s.enc, inp)
 	if err != nil {
 		return err
 	}
-	b = append(b, '\n')
 
 	_, err = s.stdin.Write(b)
 
@@ -69,14 +112,23 @@ func (s *Sandbox) Send(inp any) error {
 }
 
 func (s *Sandbox) RecvOutput(ctx context.Context, v any) error {
-	line, err := readLine(ctx, s.outR)
+	var (
+		frame []byte
+		err   error
+	)
+
+	if s.enc == EncodingMsgpack {
+		frame, err = readFrame(ctx, s.outR)
+	} else {
+		frame, err = readLine(ctx, s.outR)
+	}
 	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(line, v)
+	err = decodeFrame(s.enc, frame, v)
 	if err != nil {
-		return fmt.Errorf("unmarshal output: %v got %s", err, string(line))
+		return fmt.Errorf("unmarshal output: %v got %s", err, string(frame))
 	}
 
 	return nil
@@ -87,6 +139,13 @@ func (s *Sandbox) RecvError(ctx context.Context) ([]byte, error) {
 }
 
 func (s *Sandbox) Destroy() error {
+	s.destroyOnce.Do(s.destroy)
+	return nil
+}
+
+func (s *Sandbox) destroy() {
+	close(s.done)
+
 	if s.cmd.Process != nil {
 		_ = s.cmd.Process.Kill()
 		_ = s.cmd.Wait()
@@ -96,7 +155,9 @@ func (s *Sandbox) Destroy() error {
 	_ = s.stdout.Close()
 	_ = s.stderr.Close()
 
-	return nil
+	if s.cleanup != nil {
+		s.cleanup()
+	}
 }
 
 func readLine(ctx context.Context, r *bufio.Reader) ([]byte, error) {
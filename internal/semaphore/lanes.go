@@ -0,0 +1,104 @@
+package semaphore
+
+import "sync"
+
+// Lanes is one pool of slots shared by several lanes. Any lane may take a
+// free slot, so no slot idles while some lane has work. Once the pool is full
+// a freed slot goes to the waiting lane furthest below its share, so under
+// contention every lane converges to its share of the pool.
+type Lanes struct {
+	mu    sync.Mutex
+	size  int
+	used  int
+	lanes []laneSlots
+}
+
+type laneSlots struct {
+	share   int
+	used    int
+	waiting []chan struct{}
+}
+
+func NewLanes(size int, shares []int) *Lanes {
+	s := &Lanes{size: size, lanes: make([]laneSlots, len(shares))}
+	for i, share := range shares {
+		s.lanes[i].share = share
+	}
+	return s
+}
+
+// Acquire blocks until lane gets a slot
+func (s *Lanes) Acquire(lane int) {
+	s.mu.Lock()
+
+	// waiters only exist while the pool is full
+	if s.used < s.size {
+		s.used++
+		s.lanes[lane].used++
+		s.mu.Unlock()
+		return
+	}
+
+	ch := make(chan struct{})
+	s.lanes[lane].waiting = append(s.lanes[lane].waiting, ch)
+	s.mu.Unlock()
+
+	<-ch
+}
+
+func (s *Lanes) Release(lane int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.used--
+	s.lanes[lane].used--
+	s.dispatch()
+}
+
+// Resize changes the pool and the shares, which must keep the number of
+// lanes. Shrinking never preempts holders, it only stops new acquires until
+// enough of them release.
+func (s *Lanes) Resize(size int, shares []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.size = size
+	for i, share := range shares {
+		s.lanes[i].share = share
+	}
+	s.dispatch()
+}
+
+// Used returns the slots lane holds
+func (s *Lanes) Used(lane int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lanes[lane].used
+}
+
+// dispatch hands free slots to waiters, lowest used/share first and the
+// earlier lane on a tie
+func (s *Lanes) dispatch() {
+	for s.used < s.size {
+		next := -1
+		for i, l := range s.lanes {
+			if len(l.waiting) == 0 {
+				continue
+			}
+			if next == -1 || l.used*s.lanes[next].share < s.lanes[next].used*l.share {
+				next = i
+			}
+		}
+		if next == -1 {
+			return
+		}
+
+		l := &s.lanes[next]
+		ch := l.waiting[0]
+		l.waiting = l.waiting[1:]
+		l.used++
+		s.used++
+		close(ch)
+	}
+}
@@ -0,0 +1,182 @@
+package semaphore
+
+import (
+	"testing"
+	"time"
+)
+
+// acquire takes a slot of lane in the background and reports the lane on got
+func acquire(s *Lanes, lane int, got chan<- int) {
+	go func() {
+		s.Acquire(lane)
+		got <- lane
+	}()
+}
+
+// waitQueued waits until lane has n waiters
+func waitQueued(t *testing.T, s *Lanes, lane, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		queued := len(s.lanes[lane].waiting)
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lane %d has %d waiters, want %d", lane, queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func granted(t *testing.T, got <-chan int) int {
+	t.Helper()
+
+	select {
+	case lane := <-got:
+		return lane
+	case <-time.After(time.Second):
+		t.Fatal("no slot granted")
+		return -1
+	}
+}
+
+func notGranted(t *testing.T, got <-chan int) {
+	t.Helper()
+
+	select {
+	case lane := <-got:
+		t.Fatalf("lane %d got a slot", lane)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestLanesBorrowIdleSlots(t *testing.T) {
+	s := NewLanes(4, []int{1, 3})
+
+	// lane 0 may fill the pool while lane 1 has nothing queued
+	for range 4 {
+		s.Acquire(0)
+	}
+	if used := s.Used(0); used != 4 {
+		t.Fatalf("lane 0 holds %d slots, want 4", used)
+	}
+
+	got := make(chan int, 4)
+	acquire(s, 1, got)
+	waitQueued(t, s, 1, 1)
+	notGranted(t, got)
+
+	s.Release(0)
+	if lane := granted(t, got); lane != 1 {
+		t.Fatalf("lane %d got the slot, want 1", lane)
+	}
+}
+
+func TestLanesDispatchByShare(t *testing.T) {
+	s := NewLanes(4, []int{3, 1})
+	for range 4 {
+		s.Acquire(0)
+	}
+
+	got := make(chan int, 4)
+	for range 2 {
+		acquire(s, 0, got)
+	}
+	waitQueued(t, s, 0, 2)
+	for range 2 {
+		acquire(s, 1, got)
+	}
+	waitQueued(t, s, 1, 2)
+
+	// lane 0 holds 4 of its 3, lane 1 none of its 1
+	s.Release(0)
+	if lane := granted(t, got); lane != 1 {
+		t.Fatalf("first slot went to lane %d, want 1", lane)
+	}
+
+	// 2/3 for lane 0 against 1/1 for lane 1
+	s.Release(0)
+	if lane := granted(t, got); lane != 0 {
+		t.Fatalf("second slot went to lane %d, want 0", lane)
+	}
+
+	// 2/3 against 1/1 again
+	s.Release(0)
+	if lane := granted(t, got); lane != 0 {
+		t.Fatalf("third slot went to lane %d, want 0", lane)
+	}
+
+	// lane 1 is back to its share once lane 0 is at its own
+	s.Release(1)
+	if lane := granted(t, got); lane != 1 {
+		t.Fatalf("fourth slot went to lane %d, want 1", lane)
+	}
+
+	if u0, u1 := s.Used(0), s.Used(1); u0 != 3 || u1 != 1 {
+		t.Fatalf("lanes hold %d and %d slots, want 3 and 1", u0, u1)
+	}
+}
+
+func TestLanesTieGoesToEarlierLane(t *testing.T) {
+	s := NewLanes(2, []int{1, 1})
+	s.Acquire(0)
+	s.Acquire(1)
+
+	got := make(chan int, 2)
+	acquire(s, 1, got)
+	waitQueued(t, s, 1, 1)
+	acquire(s, 0, got)
+	waitQueued(t, s, 0, 1)
+
+	s.Release(0)
+	if lane := granted(t, got); lane != 0 {
+		t.Fatalf("tie went to lane %d, want 0", lane)
+	}
+}
+
+func TestLanesResize(t *testing.T) {
+	s := NewLanes(2, []int{1, 1})
+	s.Acquire(0)
+	s.Acquire(0)
+
+	got := make(chan int, 4)
+	acquire(s, 1, got)
+	waitQueued(t, s, 1, 1)
+
+	// growing hands the new slot out right away
+	s.Resize(3, []int{1, 2})
+	if lane := granted(t, got); lane != 1 {
+		t.Fatalf("lane %d got the new slot, want 1", lane)
+	}
+
+	// shrinking keeps the holders, a freed slot only goes out once the pool
+	// is below its new size
+	s.Resize(1, []int{1, 1})
+	acquire(s, 1, got)
+	waitQueued(t, s, 1, 1)
+
+	s.Release(0)
+	notGranted(t, got)
+	s.Release(0)
+	notGranted(t, got)
+	s.Release(1)
+	if lane := granted(t, got); lane != 1 {
+		t.Fatalf("lane %d got the slot, want 1", lane)
+	}
+
+	// new shares decide the next dispatch
+	acquire(s, 0, got)
+	waitQueued(t, s, 0, 1)
+	acquire(s, 1, got)
+	waitQueued(t, s, 1, 1)
+	s.Resize(2, []int{1, 3})
+	// lane 0 at 0/1, lane 1 at 1/3
+	if lane := granted(t, got); lane != 0 {
+		t.Fatalf("lane %d got the slot, want 0", lane)
+	}
+	notGranted(t, got)
+}
@@ -0,0 +1,230 @@
+// Package spectate streams running matches to spectators over server-sent events
+package spectate
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/delta/code-runner/internal/admin"
+	"github.com/delta/code-runner/internal/engine"
+)
+
+// entries a feed keeps after its latest view, and a spectator may lag behind
+const backlogSize = 64
+
+// Hub holds the feed of every running match, keyed by match ID
+type Hub struct {
+	mu    sync.Mutex
+	feeds map[string]*Feed
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		feeds: make(map[string]*Feed),
+	}
+}
+
+// Open starts the feed of a match, replacing a stale one with the same ID
+func (h *Hub) Open(matchID string) *Feed {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if old, ok := h.feeds[matchID]; ok {
+		old.close()
+	}
+	f := &Feed{subs: make(map[chan []byte]struct{})}
+	h.feeds[matchID] = f
+	return f
+}
+
+// Close ends the feed of a match, spectators get an end event
+func (h *Hub) Close(matchID string, f *Feed) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// a newer match with the same ID keeps its feed
+	if h.feeds[matchID] == f {
+		delete(h.feeds, matchID)
+	}
+	f.close()
+}
+
+func (h *Hub) feed(matchID string) *Feed {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.feeds[matchID]
+}
+
+// ListenAndServe serves the spectating routes on addr. It is a listener of
+// its own so spectators can be let in without reaching the admin API.
+//
+//	GET /matches/live       IDs of the running matches
+//	GET /matches/{id}/live  event stream of one match
+func (h *Hub) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /matches/live", h.list)
+	mux.HandleFunc("GET /matches/{id}/live", h.stream)
+
+	log.Printf("spectator api listening on %s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func (h *Hub) list(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	ids := make([]string, 0, len(h.feeds))
+	for id := range h.feeds {
+		ids = append(ids, id)
+	}
+	h.mu.Unlock()
+
+	slices.Sort(ids)
+	admin.WriteJSON(w, http.StatusOK, ids)
+}
+
+// stream sends the latest view and what followed it, then every entry as it
+// is published. Each entry is an SSE event named after its log type (VIEW,
+// MOVE, EVENT, DEBUG) with the logged message as data, and an "end" event
+// follows the last one.
+func (h *Hub) stream(w http.ResponseWriter, r *http.Request) {
+	f := h.feed(r.PathValue("id"))
+	if f == nil {
+		admin.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "no running match with this id"})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		admin.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+
+	backlog, ch, cancel := f.subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range backlog {
+		w.Write(ev)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				// the match ended, or this spectator fell too far behind and
+				// has to reconnect for the current state
+				fmt.Fprint(w, "event: end\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			w.Write(ev)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Feed is the stream of one match. Publishing never blocks the match.
+type Feed struct {
+	mu      sync.Mutex
+	backlog []entry // latest view first
+	subs    map[chan []byte]struct{}
+	closed  bool
+}
+
+type entry struct {
+	typ engine.GameLogType
+	msg any
+	ev  []byte // encoded on first use, nobody may be watching
+}
+
+func (e *entry) encode() []byte {
+	if e.ev == nil {
+		data, err := json.Marshal(e.msg)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"error": err.Error()})
+		}
+		e.ev = fmt.Appendf(nil, "event: %s\ndata: %s\n\n", e.typ, data)
+	}
+	return e.ev
+}
+
+// Publish implements engine.Spectator. msg must not be changed afterwards.
+func (f *Feed) Publish(typ engine.GameLogType, msg any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	e := entry{typ: typ, msg: msg}
+	if len(f.subs) > 0 {
+		e.encode()
+	}
+
+	switch {
+	case typ == engine.GameLogGameView:
+		f.backlog = append(f.backlog[:0], e)
+	case len(f.backlog) < backlogSize:
+		f.backlog = append(f.backlog, e)
+	}
+
+	for ch := range f.subs {
+		select {
+		case ch <- e.ev:
+		default:
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (f *Feed) subscribe() (backlog [][]byte, ch chan []byte, cancel func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.backlog {
+		backlog = append(backlog, f.backlog[i].encode())
+	}
+
+	ch = make(chan []byte, backlogSize)
+	if f.closed {
+		close(ch)
+		return backlog, ch, func() {}
+	}
+	f.subs[ch] = struct{}{}
+
+	return backlog, ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if _, ok := f.subs[ch]; ok {
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (f *Feed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.closed = true
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
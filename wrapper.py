@@ -1,29 +1,95 @@
 # main.py
+import importlib
 import json
+import os
+import struct
 import sys
 from typing import Callable
 
+try:
+    import msgpack
+except ImportError:
+    msgpack = None
+
 from oceanmaster.api import GameAPI
 from oceanmaster.models.player_view import PlayerView
 from oceanmaster.context.bot_context import BotContext
 from oceanmaster.botbase import BotController
 from oceanmaster.constants import Ability
-from submission import spawn_policy as _spawn_policy # in sandbox submission dir will present and main.py inside represents the user code
 
 class _WrapperState:
     def __init__(self):
         self.bot_strategies: dict[int, BotController] = {}
-        self.spawn_policy: Callable[[GameAPI], list[dict]] = _spawn_policy
-        self.curr_bot_id: int = -1
+        self.spawn_policy: Callable[[GameAPI], list[dict]] | None = None
 
 _STATE = _WrapperState()
 
-def play(api: GameAPI):
+PROTOCOL_VERSIONS = [2, 1]
+
+# all engine I/O goes through the binary streams so switching to
+# length-prefixed frames never loses bytes buffered by the text layer
+_IN = sys.stdin.buffer
+_OUT = sys.stdout.buffer
+
+class _Conn:
+    def __init__(self):
+        self.encoding = "json"
+
+    def send(self, obj):
+        if self.encoding == "msgpack":
+            body = msgpack.packb(obj)
+            _OUT.write(struct.pack(">I", len(body)) + body)
+        else:
+            _OUT.write(json.dumps(obj).encode() + b"\n")
+        _OUT.flush()
+
+    def recv(self):
+        if self.encoding == "msgpack":
+            hdr = _IN.read(4)
+            if len(hdr) < 4:
+                return None
+            (n,) = struct.unpack(">I", hdr)
+            return msgpack.unpackb(_IN.read(n))
+
+        line = _IN.readline()
+        if not line:
+            return None
+        return json.loads(line)
+
+_CONN = _Conn()
+
+def handshake():
+    # structured hello, the engine answers with the version and encoding to use
+    encodings = ["msgpack", "json"] if msgpack is not None else ["json"]
+    _CONN.send({"hello": {"versions": PROTOCOL_VERSIONS, "encodings": encodings}})
+    welcome = _CONN.recv()
+    if welcome is None:
+        sys.exit(0)
+    _CONN.encoding = welcome["encoding"]
+
+def load_submission(module: str = "submission"):
+    # in sandbox submission dir will present and main.py inside represents the user code
+    # warm jails get the files after startup, so drop any stale directory listings
+    importlib.invalidate_caches()
+    _STATE.spawn_policy = importlib.import_module(module).spawn_policy
+
+def wait_for_load() -> str | None:
+    # warm pool: the SDK is imported, wait until the runner says which submission to load
+    _CONN.send("__WARM_V1__")
+    cmd = _CONN.recv()
+    if cmd is None:
+        return None
+    return cmd["load"]
+
+def play(api: GameAPI, mode: str = "ALTERNATE", bot_id_max: int | None = None):
     tick = api.get_tick()
 
     # linearize tick for the user algo
     # because user algo might do `if tick % 10 then spawn bot`
-    if tick % 2 == 1:
+    # in simultaneous mode every tick is already a full turn for both players
+    if mode == "SIMULTANEOUS":
+        pass
+    elif tick % 2 == 1:
         api.view.tick = tick//2 + 1
     else:
         api.view.tick = tick//2
@@ -31,8 +97,12 @@ def play(api: GameAPI):
     spawns: dict[str, dict] = {}
     actions: dict[str, dict] = {}
 
-    if _STATE.curr_bot_id == -1:
-        _STATE.curr_bot_id = api.view.bot_id_seed
+    # lowest IDs without a live bot, so dead bots and rejected spawns
+    # give theirs back and a player never runs out of the range
+    if bot_id_max is None:
+        bot_id_max = api.view.bot_id_seed + 99
+    alive = {bot.id for bot in api.get_my_bots()}
+    free_ids = (i for i in range(api.view.bot_id_seed, bot_id_max + 1) if i not in alive)
 
     # ---- SPAWN PHASE (EVERY TICK) ----
     for spec in _STATE.spawn_policy(api):
@@ -49,8 +119,9 @@ def play(api: GameAPI):
         # if api.view.bot_count >= api.view.max_bots:
         #     continue
 
-        bot_id = _STATE.curr_bot_id
-        _STATE.curr_bot_id += 1
+        bot_id = next(free_ids, None)
+        if bot_id is None:
+            break
 
         spawns[str(bot_id)] = {
             "abilities": abilities,
@@ -93,21 +164,26 @@ def play(api: GameAPI):
     }
 
 def main():
-    print("\"__READY_V1__\"", flush=True)
+    module = "submission"
+    if os.environ.get("OCEANMASTER_WARM") == "1":
+        module = wait_for_load()
+        if module is None:
+            return
+
+    load_submission(module)
+
+    handshake()
     while True:
-        line = sys.stdin.readline()
-        if not line:
+        data = _CONN.recv()
+        if data is None:
             break
 
-        data = json.loads(line)
-
         view = PlayerView.from_dict(data)
 
         api = GameAPI(view)
-        out = play(api)
+        out = play(api, data.get("mode", "ALTERNATE"), data.get("bot_id_max"))
 
-        print(json.dumps(out))
-        sys.stdout.flush()
+        _CONN.send(out)
 
 
 if __name__ == "__main__":